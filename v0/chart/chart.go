@@ -0,0 +1,123 @@
+// Package chart renders the dynamic README elements as SVG without going through HTTP.
+//
+// Each chart has an options struct and a Render function writing the SVG to an io.Writer.
+// The HTTP endpoints are thin adapters over the same functions, so the output is identical.
+package chart
+
+import (
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/svggen"
+	"io"
+)
+
+// BarOptions configures a linear progress bar.
+type BarOptions = svggen.BarOptions
+
+// BarSegment is one named portion of a stacked progress bar.
+type BarSegment = svggen.BarSegment
+
+// CircleOptions configures a circular progress bar.
+type CircleOptions = svggen.CircleOptions
+
+// GaugeOptions configures a semi-circular progress gauge.
+type GaugeOptions = svggen.GaugeOptions
+
+// WaffleOptions configures a waffle progress chart.
+type WaffleOptions = svggen.WaffleOptions
+
+// CalendarOptions configures a monthly calendar chart.
+type CalendarOptions = svggen.CalendarOptions
+
+// CalendarCategory is a named set of days drawn in its own color on a monthly calendar.
+type CalendarCategory = svggen.CalendarCategory
+
+// YearCalendarOptions configures a year calendar heatmap.
+type YearCalendarOptions = svggen.YearCalendarOptions
+
+// DayCount is the count recorded for one day of a year calendar.
+type DayCount = svggen.DayCount
+
+// BadgeOptions configures a shields-style badge showing a label and a message.
+type BadgeOptions = svggen.BadgeOptions
+
+// Badge styles accepted by the Style field of BadgeOptions.
+const (
+	BadgeStyleFlat        = svggen.BadgeStyleFlat
+	BadgeStyleFlatSquare  = svggen.BadgeStyleFlatSquare
+	BadgeStylePlastic     = svggen.BadgeStylePlastic
+	BadgeStyleForTheBadge = svggen.BadgeStyleForTheBadge
+)
+
+// Threshold colors a progress chart from a percentage up to the next threshold.
+type Threshold = svggen.Threshold
+
+// ColorOverrides replace colors of the theme for a single chart.
+type ColorOverrides = svggen.ColorOverrides
+
+// Animation makes a progress chart fill in from zero when it is shown.
+type Animation = svggen.Animation
+
+// ParamError reports an option value that cannot be rendered.
+type ParamError = svggen.ParamError
+
+// DefaultBarOptions returns the options the /progress/bar endpoint starts from.
+func DefaultBarOptions() BarOptions { return svggen.DefaultBarOptions() }
+
+// DefaultCircleOptions returns the options the /progress/circle endpoint starts from.
+func DefaultCircleOptions() CircleOptions { return svggen.DefaultCircleOptions() }
+
+// DefaultGaugeOptions returns the options the /progress/gauge endpoint starts from.
+func DefaultGaugeOptions() GaugeOptions { return svggen.DefaultGaugeOptions() }
+
+// DefaultWaffleOptions returns the options the /progress/waffle endpoint starts from.
+func DefaultWaffleOptions() WaffleOptions { return svggen.DefaultWaffleOptions() }
+
+// DefaultCalendarOptions returns the options the /calendar endpoint starts from.
+func DefaultCalendarOptions() CalendarOptions { return svggen.DefaultCalendarOptions() }
+
+// DefaultYearCalendarOptions returns the options the /calendar/year endpoint starts from.
+func DefaultYearCalendarOptions() YearCalendarOptions { return svggen.DefaultYearCalendarOptions() }
+
+// DefaultBadgeOptions returns the options the /badge endpoint starts from.
+func DefaultBadgeOptions() BadgeOptions { return svggen.DefaultBadgeOptions() }
+
+// RenderBar writes a linear progress bar SVG to w.
+func RenderBar(w io.Writer, opts BarOptions) error { return svggen.RenderBar(w, opts) }
+
+// RenderCircle writes a circular progress bar SVG to w.
+func RenderCircle(w io.Writer, opts CircleOptions) error { return svggen.RenderCircle(w, opts) }
+
+// RenderGauge writes a progress gauge SVG to w.
+func RenderGauge(w io.Writer, opts GaugeOptions) error { return svggen.RenderGauge(w, opts) }
+
+// RenderWaffle writes a waffle progress chart SVG to w.
+func RenderWaffle(w io.Writer, opts WaffleOptions) error { return svggen.RenderWaffle(w, opts) }
+
+// RenderCalendar writes a monthly calendar SVG to w.
+func RenderCalendar(w io.Writer, opts CalendarOptions) error { return svggen.RenderCalendar(w, opts) }
+
+// RenderYearCalendar writes a year long contribution heatmap SVG to w.
+func RenderYearCalendar(w io.Writer, opts YearCalendarOptions) error {
+	return svggen.RenderYearCalendar(w, opts)
+}
+
+// RenderBadge writes a badge SVG to w.
+func RenderBadge(w io.Writer, opts BadgeOptions) error { return svggen.RenderBadge(w, opts) }
+
+// Percentage returns how far value is between min and max as a percentage, for the
+// Percentage fields of the progress chart options.
+func Percentage(value, min, max float64) float64 { return svggen.Percentage(value, min, max) }
+
+// Locales returns the locale codes accepted by the calendar options.
+func Locales() []string { return svggen.Locales() }
+
+// Themes returns the theme names accepted by the Theme field of every options struct.
+func Themes() []string { return svggen.Themes() }
+
+// Logos returns the logo names accepted by the Logo field of BadgeOptions.
+func Logos() []string { return svggen.Logos() }
+
+// Easings returns the easing names accepted by the Easing field of an Animation.
+func Easings() []string { return svggen.Easings() }
+
+// PNG rasterizes an SVG produced by one of the Render functions and writes it to w as a PNG.
+func PNG(w io.Writer, svg []byte) error { return svggen.Encode(w, svg, svggen.FormatPNG) }
@@ -0,0 +1,144 @@
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ExampleRenderBar() {
+	opts := DefaultBarOptions()
+	opts.Percentage = 72
+
+	if err := RenderBar(os.Stdout, opts); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	testCases := []struct {
+		name           string
+		render         func(*bytes.Buffer) error
+		expectedInBody []string
+	}{
+		{
+			name: "Bar",
+			render: func(buf *bytes.Buffer) error {
+				return RenderBar(buf, BarOptions{Width: 221, Height: 33, Percentage: 54})
+			},
+			expectedInBody: []string{`<svg width="221px" height="33px"`, `width="119px"`, "54%"},
+		},
+		{
+			name: "Circle",
+			render: func(buf *bytes.Buffer) error {
+				return RenderCircle(buf, CircleOptions{Size: 103, Percentage: 58})
+			},
+			expectedInBody: []string{`stroke-dasharray="132.9476, 96.2724"`, "58%"},
+		},
+		{
+			name: "Gauge",
+			render: func(buf *bytes.Buffer) error {
+				return RenderGauge(buf, GaugeOptions{Width: 200, Percentage: 50})
+			},
+			expectedInBody: []string{`<svg height="100px" width="200px"`},
+		},
+		{
+			name: "Waffle",
+			render: func(buf *bytes.Buffer) error {
+				return RenderWaffle(buf, WaffleOptions{Width: 300, NumberOfSquares: 10, Percentage: 50})
+			},
+			expectedInBody: []string{`class="gridSquare"`},
+		},
+		{
+			name: "Calendar",
+			render: func(buf *bytes.Buffer) error {
+				return RenderCalendar(buf, CalendarOptions{Year: 2023, Month: time.January, ProgressDays: []int{1}})
+			},
+			expectedInBody: []string{"January 2023", `<rect x="15" y="45" width="40" height="40" fill="#4c1" stroke="#ddd" />`},
+		},
+		{
+			name: "Bar with thresholds",
+			render: func(buf *bytes.Buffer) error {
+				return RenderBar(buf, BarOptions{Width: 100, Height: 20, Percentage: 30, Thresholds: []Threshold{{From: 0, Color: "red"}, {From: 50, Color: "#00FF00"}}})
+			},
+			expectedInBody: []string{`width="30px" height="20px" fill="red"`},
+		},
+		{
+			name: "Badge",
+			render: func(buf *bytes.Buffer) error {
+				opts := DefaultBadgeOptions()
+				opts.Label, opts.Message, opts.Style = "coverage", "87%", BadgeStyleFlatSquare
+				return RenderBadge(buf, opts)
+			},
+			expectedInBody: []string{">coverage</text>", ">87%</text>"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tc.render(&buf); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, str := range tc.expectedInBody {
+				if !strings.Contains(buf.String(), str) {
+					t.Errorf("Expected to find %s in output", str)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderCalendarInvalidMonth(t *testing.T) {
+	var buf bytes.Buffer
+	err := RenderCalendar(&buf, CalendarOptions{Year: 2023, Month: 13})
+
+	var paramErr *ParamError
+	if !errors.As(err, &paramErr) {
+		t.Fatalf("Expected a *ParamError, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing to be written, got %s", buf.String())
+	}
+}
+
+func TestRenderInvalidSize(t *testing.T) {
+	testCases := []struct {
+		name          string
+		render        func(*bytes.Buffer) error
+		expectedError string
+	}{
+		{"Bar zero width", func(buf *bytes.Buffer) error { return RenderBar(buf, BarOptions{Width: 0, Height: 20}) }, "Width and height must be at least 1"},
+		{"Bar negative height", func(buf *bytes.Buffer) error { return RenderBar(buf, BarOptions{Width: 100, Height: -1}) }, "Width and height must be at least 1"},
+		{"Circle zero size", func(buf *bytes.Buffer) error { return RenderCircle(buf, CircleOptions{Size: 0}) }, "Size must be greater than twice the stroke width"},
+		{"Gauge zero width", func(buf *bytes.Buffer) error { return RenderGauge(buf, GaugeOptions{Width: 0}) }, "Width must be at least 1"},
+		{"Gauge negative width", func(buf *bytes.Buffer) error { return RenderGauge(buf, GaugeOptions{Width: -5}) }, "Width must be at least 1"},
+		{"Waffle narrow", func(buf *bytes.Buffer) error { return RenderWaffle(buf, WaffleOptions{Width: 9, NumberOfSquares: 10}) }, "Width must be at least 10"},
+		{"Waffle zero squares", func(buf *bytes.Buffer) error { return RenderWaffle(buf, WaffleOptions{Width: 100}) }, "Number of squares must be between 1 and 10000"},
+		{"Waffle negative squares", func(buf *bytes.Buffer) error {
+			return RenderWaffle(buf, WaffleOptions{Width: 100, NumberOfSquares: -1})
+		}, "Number of squares must be between 1 and 10000"},
+		{"Waffle too many squares", func(buf *bytes.Buffer) error {
+			return RenderWaffle(buf, WaffleOptions{Width: 100, NumberOfSquares: 10001})
+		}, "Number of squares must be between 1 and 10000"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := tc.render(&buf)
+
+			var paramErr *ParamError
+			if !errors.As(err, &paramErr) || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("Expected a *ParamError containing %q, got %v", tc.expectedError, err)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("Expected nothing to be written, got %s", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Package buildinfo reads the build metadata the Go toolchain records in the binary.
+// It is the one place that calls debug.ReadBuildInfo, so the ETags, /version and
+// /metrics agree on which build is running.
+package buildinfo
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// Info is the build metadata of the running binary. Fields are empty when the
+// toolchain did not record them, such as the VCS fields of a build outside a checkout.
+type Info struct {
+	Version    string // Module version, empty for "(devel)" builds
+	Revision   string // Commit the binary was built from
+	CommitTime string // Time of the commit, RFC 3339
+	Dirty      bool   // Whether the working tree had uncommitted changes
+	GoVersion  string // Version of the toolchain
+	Module     string // Module path of the main package
+}
+
+// Read returns the metadata of the running binary. It is read once.
+var Read = sync.OnceValue(func() Info {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return Info{}
+	}
+	return parse(info)
+})
+
+func parse(info *debug.BuildInfo) Info {
+	v := Info{
+		Revision:   Setting(info.Settings, "vcs.revision"),
+		CommitTime: Setting(info.Settings, "vcs.time"),
+		Dirty:      Setting(info.Settings, "vcs.modified") == "true",
+		GoVersion:  info.GoVersion,
+		Module:     info.Main.Path,
+	}
+	if info.Main.Version != "(devel)" {
+		v.Version = info.Main.Version
+	}
+	return v
+}
+
+// Setting returns the value of the build setting named key,
+// or an empty string if the binary does not record it.
+func Setting(settings []debug.BuildSetting, key string) string {
+	for _, setting := range settings {
+		if setting.Key == key {
+			return setting.Value
+		}
+	}
+	return ""
+}
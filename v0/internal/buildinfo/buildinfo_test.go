@@ -0,0 +1,40 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		info     debug.BuildInfo
+		expected Info
+	}{
+		{
+			name: "Release with VCS information",
+			info: debug.BuildInfo{
+				GoVersion: "go1.22.0",
+				Main:      debug.Module{Path: "example.com/app", Version: "v1.4.2"},
+				Settings: []debug.BuildSetting{
+					{Key: "vcs.revision", Value: "d2dc93a"},
+					{Key: "vcs.time", Value: "2024-05-01T12:00:00Z"},
+					{Key: "vcs.modified", Value: "true"},
+				},
+			},
+			expected: Info{Version: "v1.4.2", Revision: "d2dc93a", CommitTime: "2024-05-01T12:00:00Z", Dirty: true, GoVersion: "go1.22.0", Module: "example.com/app"},
+		},
+		{
+			name:     "Development build",
+			info:     debug.BuildInfo{GoVersion: "go1.22.0", Main: debug.Module{Path: "example.com/app", Version: "(devel)"}},
+			expected: Info{GoVersion: "go1.22.0", Module: "example.com/app"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := parse(&tc.info); actual != tc.expected {
+				t.Errorf("parse() = %+v; expected %+v", actual, tc.expected)
+			}
+		})
+	}
+}
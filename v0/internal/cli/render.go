@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/svggen"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const renderUsage = `Usage: v0 render <chart> [--<param> <value> ...] [--format svg|png] [-o <file>]
+
+Renders a chart without starting the server. Parameters are the same as the
+query parameters accepted by the HTTP endpoint for the chart, for example:
+
+  v0 render bar --percentage 72 -o bar.svg
+  v0 render calendar --year=2024 --month=3 --progressDays=1,2,3
+
+The chart is written to stdout when no output file is given or the file is "-".
+The format defaults to PNG for output files ending in .png and SVG otherwise.
+
+Charts: %s
+`
+
+// renderArgs holds the parsed arguments of the render subcommand.
+type renderArgs struct {
+	chart  string
+	output string
+	format string
+	params url.Values
+}
+
+// Render runs the render subcommand with args (excluding the subcommand name)
+// and returns the process exit code.
+func Render(args []string, stdout, stderr io.Writer) int {
+	usage := fmt.Sprintf(renderUsage, strings.Join(svggen.ChartNames(), ", "))
+
+	parsed, err := parseRenderArgs(args)
+	if errors.Is(err, errHelp) {
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "render: %v\n\n%s", err, usage)
+		return 2
+	}
+
+	var svg, buf bytes.Buffer
+	err = svggen.RenderChart(&svg, parsed.chart, parsed.params)
+	if err == nil {
+		err = svggen.Encode(&buf, svg.Bytes(), parsed.format)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "render: %v\n", err)
+		return 1
+	}
+
+	if parsed.output == "" || parsed.output == "-" {
+		if _, err := stdout.Write(buf.Bytes()); err != nil {
+			fmt.Fprintf(stderr, "render: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if err := os.WriteFile(parsed.output, buf.Bytes(), 0o644); err != nil {
+		fmt.Fprintf(stderr, "render: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+var errHelp = errors.New("help requested")
+
+// parseRenderArgs parses "<chart> [--param value | --param=value ...] [-o file]".
+// Chart parameters are not declared up front, so any long flag is accepted and
+// passed through as a query parameter. Repeating a parameter appends a value.
+func parseRenderArgs(args []string) (renderArgs, error) {
+	parsed := renderArgs{params: url.Values{}}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-h" || arg == "-help" || arg == "--help" {
+			return parsed, errHelp
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if parsed.chart != "" {
+				return parsed, fmt.Errorf("unexpected argument %q", arg)
+			}
+			parsed.chart = arg
+			continue
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		value, hasValue := "", false
+		if idx := strings.Index(name, "="); idx >= 0 {
+			name, value, hasValue = name[:idx], name[idx+1:], true
+		}
+		if name == "" {
+			return parsed, fmt.Errorf("invalid flag %q", arg)
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return parsed, fmt.Errorf("flag %q requires a value", arg)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch name {
+		case "o", "output":
+			parsed.output = value
+			continue
+		case "format":
+			parsed.format = value
+			continue
+		}
+		parsed.params.Add(name, value)
+	}
+
+	if parsed.chart == "" {
+		return parsed, errors.New("missing chart name")
+	}
+	if parsed.format == "" {
+		parsed.format = svggen.FormatSVG
+		if strings.EqualFold(filepath.Ext(parsed.output), ".png") {
+			parsed.format = svggen.FormatPNG
+		}
+	}
+	return parsed, nil
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRenderArgs(t *testing.T) {
+	testCases := []struct {
+		name           string
+		args           []string
+		expectedChart  string
+		expectedOutput string
+		expectedFormat string
+		expectedParams map[string][]string
+		expectErr      bool
+	}{
+		{
+			name:           "Separate values",
+			args:           []string{"bar", "--percentage", "72", "-o", "bar.svg"},
+			expectedChart:  "bar",
+			expectedOutput: "bar.svg",
+			expectedFormat: "svg",
+			expectedParams: map[string][]string{"percentage": {"72"}},
+		},
+		{
+			name:           "Inline values",
+			args:           []string{"--year=2024", "calendar", "--progressDays=1,2", "--output=cal.svg"},
+			expectedChart:  "calendar",
+			expectedOutput: "cal.svg",
+			expectedFormat: "svg",
+			expectedParams: map[string][]string{"year": {"2024"}, "progressDays": {"1,2"}},
+		},
+		{
+			name:           "Negative value",
+			args:           []string{"gauge", "--percentage", "-5"},
+			expectedChart:  "gauge",
+			expectedFormat: "svg",
+			expectedParams: map[string][]string{
+				"percentage": {"-5"},
+			},
+		},
+		{
+			name:           "Format from extension",
+			args:           []string{"waffle", "-o", "waffle.PNG"},
+			expectedChart:  "waffle",
+			expectedOutput: "waffle.PNG",
+			expectedFormat: "png",
+			expectedParams: map[string][]string{},
+		},
+		{
+			name:           "Explicit format",
+			args:           []string{"waffle", "--format", "png"},
+			expectedChart:  "waffle",
+			expectedFormat: "png",
+			expectedParams: map[string][]string{},
+		},
+		{
+			name:      "Missing chart",
+			args:      []string{"--percentage", "72"},
+			expectErr: true,
+		},
+		{
+			name:      "Missing value",
+			args:      []string{"bar", "--percentage"},
+			expectErr: true,
+		},
+		{
+			name:      "Two charts",
+			args:      []string{"bar", "circle"},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed, err := parseRenderArgs(tc.args)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("Expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if parsed.chart != tc.expectedChart {
+				t.Errorf("Expected chart %q, got %q", tc.expectedChart, parsed.chart)
+			}
+			if parsed.output != tc.expectedOutput {
+				t.Errorf("Expected output %q, got %q", tc.expectedOutput, parsed.output)
+			}
+			if parsed.format != tc.expectedFormat {
+				t.Errorf("Expected format %q, got %q", tc.expectedFormat, parsed.format)
+			}
+			if !reflect.DeepEqual(map[string][]string(parsed.params), tc.expectedParams) {
+				t.Errorf("Expected params %v, got %v", tc.expectedParams, parsed.params)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Run("Writes file", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "bar.svg")
+		var stdout, stderr bytes.Buffer
+
+		code := Render([]string{"bar", "--percentage", "72", "-o", output}, &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+		}
+
+		content, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatalf("Expected output file: %v", err)
+		}
+		if !strings.Contains(string(content), "72%") {
+			t.Errorf("Expected rendered bar in output file, got %s", content)
+		}
+	})
+
+	t.Run("Writes stdout", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		code := Render([]string{"circle", "--size", "103", "--percentage", "58"}, &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), `stroke-dasharray="132.9476, 96.2724"`) {
+			t.Errorf("Expected rendered circle on stdout, got %s", stdout.String())
+		}
+	})
+
+	t.Run("Writes PNG for .png files", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "gauge.png")
+		var stdout, stderr bytes.Buffer
+
+		if code := Render([]string{"gauge", "--percentage", "40", "-o", output}, &stdout, &stderr); code != 0 {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+		}
+
+		content, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatalf("Expected output file: %v", err)
+		}
+		if !bytes.HasPrefix(content, []byte("\x89PNG")) {
+			t.Errorf("Expected a PNG file")
+		}
+	})
+
+	t.Run("Unknown chart", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		if code := Render([]string{"pie"}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+		if !strings.Contains(stderr.String(), `unknown chart "pie"`) {
+			t.Errorf("Expected unknown chart error, got %s", stderr.String())
+		}
+	})
+
+	t.Run("Invalid parameter", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "calendar.svg")
+		var stdout, stderr bytes.Buffer
+
+		if code := Render([]string{"calendar", "--month", "13", "-o", output}, &stdout, &stderr); code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+		if _, err := os.Stat(output); !os.IsNotExist(err) {
+			t.Errorf("Expected no output file for a failed render")
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/readme"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const updateUsage = `Usage: v0 update [-check] <file.md> [<file.md> ...]
+
+Re-renders every chart marker in the given Markdown files in place. A marker is
+a pair of HTML comments naming a chart and its parameters:
+
+  <!-- dre:bar percentage=72 -->
+  <!-- /dre -->
+
+The text between the markers is replaced with an <img> holding the SVG as a
+data URI, or referencing a separate SVG file when the marker has a file= key
+(relative to the Markdown file, and inside its directory). An alt= key sets the
+image's alternative text.
+
+Files are only written when their content changes. With -check nothing is
+written and the exit code is 1 if any file is out of date.
+
+`
+
+// Update runs the update subcommand with args (excluding the subcommand name)
+// and returns the process exit code.
+func Update(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("update", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, updateUsage)
+		flags.PrintDefaults()
+	}
+	check := flags.Bool("check", false, "report out of date files without writing them")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	outdated := false
+	for _, path := range flags.Args() {
+		changed, err := updateFile(path, !*check)
+		if err != nil {
+			fmt.Fprintf(stderr, "update: %s: %v\n", path, err)
+			return 1
+		}
+		switch {
+		case changed && *check:
+			fmt.Fprintf(stdout, "%s: out of date\n", path)
+			outdated = true
+		case changed:
+			fmt.Fprintf(stdout, "%s: updated\n", path)
+		default:
+			fmt.Fprintf(stdout, "%s: up to date\n", path)
+		}
+	}
+
+	if outdated {
+		return 1
+	}
+	return 0
+}
+
+// updateFile re-renders the markers in the Markdown file at path and reports whether
+// the file or any of the SVG files it references changed. Nothing is written unless write is true.
+func updateFile(path string, write bool) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := readme.Update(content)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	dir := filepath.Dir(path)
+	for _, asset := range result.Assets {
+		assetPath := filepath.Join(dir, filepath.FromSlash(asset.Path))
+		existing, err := os.ReadFile(assetPath)
+		if err == nil && bytes.Equal(existing, asset.Content) {
+			continue
+		}
+		changed = true
+		if write {
+			if err := os.MkdirAll(filepath.Dir(assetPath), 0o755); err != nil {
+				return false, err
+			}
+			if err := os.WriteFile(assetPath, asset.Content, 0o644); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if !bytes.Equal(content, result.Content) {
+		changed = true
+		if write {
+			if err := os.WriteFile(path, result.Content, 0o644); err != nil {
+				return false, err
+			}
+		}
+	}
+	return changed, nil
+}
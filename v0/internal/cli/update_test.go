@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	dir := t.TempDir()
+	readmePath := filepath.Join(dir, "README.md")
+	original := "# Progress\n<!-- dre:gauge percentage=40 file=assets/gauge.svg -->\n<!-- /dre -->\n"
+	if err := os.WriteFile(readmePath, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Update([]string{"-check", readmePath}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for an out of date file, got %d: %s", code, stderr.String())
+	}
+	if content, _ := os.ReadFile(readmePath); string(content) != original {
+		t.Errorf("Expected -check to leave the file untouched")
+	}
+
+	stdout.Reset()
+	if code := Update([]string{readmePath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "updated") {
+		t.Errorf("Expected the file to be reported as updated, got %s", stdout.String())
+	}
+	content, _ := os.ReadFile(readmePath)
+	if !strings.Contains(string(content), `<img src="assets/gauge.svg" alt="gauge">`) {
+		t.Errorf("Expected an image reference in the file, got %s", content)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "assets", "gauge.svg")); err != nil {
+		t.Errorf("Expected the SVG asset to be written: %v", err)
+	}
+
+	stdout.Reset()
+	if code := Update([]string{"-check", readmePath}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected exit code 0 once up to date, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "up to date") {
+		t.Errorf("Expected the file to be reported as up to date, got %s", stdout.String())
+	}
+}
+
+func TestUpdateMissingFile(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Update([]string{filepath.Join(t.TempDir(), "missing.md")}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+}
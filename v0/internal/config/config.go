@@ -0,0 +1,349 @@
+// Package config loads the server settings from an optional YAML or TOML file and
+// DRE_* environment variables, and checks them before the server starts.
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/svggen"
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// EnvPrefix starts the name of every environment variable read by Load.
+// The rest of the name is the key of the setting in upper snake case, so
+// charts.bar.width is set by DRE_CHARTS_BAR_WIDTH.
+const EnvPrefix = "DRE_"
+
+// Config holds every server setting. The key of each field in a file is given by its
+// yaml tag, and TOML files use the same keys.
+type Config struct {
+	Addr     string   `yaml:"addr" toml:"addr"`   // Address the server listens on, such as ":8080"
+	Theme    string   `yaml:"theme" toml:"theme"` // Theme of charts whose request does not name one
+	Server   Server   `yaml:"server" toml:"server"`
+	Limits   Limits   `yaml:"limits" toml:"limits"`
+	Cache    Cache    `yaml:"cache" toml:"cache"`
+	Charts   Charts   `yaml:"charts" toml:"charts"`
+	Features Features `yaml:"features" toml:"features"`
+	Version  Version  `yaml:"version" toml:"version"`
+}
+
+// Server sets the timeouts and limits of the HTTP server.
+type Server struct {
+	ReadHeaderTimeout Duration `yaml:"readHeaderTimeout" toml:"readHeaderTimeout"` // Time to read the request headers
+	ReadTimeout       Duration `yaml:"readTimeout" toml:"readTimeout"`             // Time to read the whole request
+	WriteTimeout      Duration `yaml:"writeTimeout" toml:"writeTimeout"`           // Time from the end of the request headers to the end of the response
+	IdleTimeout       Duration `yaml:"idleTimeout" toml:"idleTimeout"`             // Time a keep-alive connection waits for its next request
+	MaxHeaderBytes    int      `yaml:"maxHeaderBytes" toml:"maxHeaderBytes"`       // Largest size of the request headers
+	ShutdownTimeout   Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout"`     // Time in-flight requests get to finish on SIGINT or SIGTERM
+}
+
+// Limits bound the sizes accepted from a request.
+type Limits struct {
+	MaxChartSize     int `yaml:"maxChartSize" toml:"maxChartSize"`         // Largest width, height or size in pixels
+	MaxWaffleSquares int `yaml:"maxWaffleSquares" toml:"maxWaffleSquares"` // Largest number of waffle squares
+}
+
+// Cache configures HTTP caching and the in-memory render cache.
+type Cache struct {
+	MaxAge          Duration `yaml:"maxAge" toml:"maxAge"`                   // Cache-Control max-age of charts with pinned parameters
+	TodayMaxAge     Duration `yaml:"todayMaxAge" toml:"todayMaxAge"`         // Cache-Control max-age of charts depending on the current date
+	RenderCacheSize int      `yaml:"renderCacheSize" toml:"renderCacheSize"` // Rendered charts kept in memory, 0 to disable
+}
+
+// Charts holds the defaults of parameters a request leaves out.
+type Charts struct {
+	Bar struct {
+		Width  int `yaml:"width" toml:"width"`
+		Height int `yaml:"height" toml:"height"`
+	} `yaml:"bar" toml:"bar"`
+	Circle struct {
+		Size        int `yaml:"size" toml:"size"`
+		StrokeWidth int `yaml:"strokeWidth" toml:"strokeWidth"`
+	} `yaml:"circle" toml:"circle"`
+	Gauge struct {
+		Width int `yaml:"width" toml:"width"`
+	} `yaml:"gauge" toml:"gauge"`
+	Waffle struct {
+		Width           int `yaml:"width" toml:"width"`
+		NumberOfSquares int `yaml:"numberOfSquares" toml:"numberOfSquares"`
+		Gap             int `yaml:"gap" toml:"gap"`
+	} `yaml:"waffle" toml:"waffle"`
+}
+
+// Features turn optional parts of the server on or off.
+type Features struct {
+	PNG     bool `yaml:"png" toml:"png"`         // Serve charts as PNG on request
+	Metrics bool `yaml:"metrics" toml:"metrics"` // Serve /metrics
+	Version bool `yaml:"version" toml:"version"` // Serve /version
+}
+
+// Tag providers /version can look up the tags of the running commit with.
+const (
+	TagProviderNone   = "none"   // Only the tags set at build time
+	TagProviderStatic = "static" // The tags listed in StaticTags
+	TagProviderGit    = "git"    // The tags of a local git repository
+	TagProviderGitHub = "github" // The tags of the repository on GitHub, through its REST API
+)
+
+// Version selects where /version finds the tags of the running commit.
+type Version struct {
+	TagProvider string   `yaml:"tagProvider" toml:"tagProvider"` // One of the TagProvider constants
+	Timeout     Duration `yaml:"timeout" toml:"timeout"`         // Time a lookup may take
+	CacheTTL    Duration `yaml:"cacheTTL" toml:"cacheTTL"`       // How long the tags found are reused
+	StaticTags  string   `yaml:"staticTags" toml:"staticTags"`   // Comma separated tags of the static provider
+	GitDir      string   `yaml:"gitDir" toml:"gitDir"`           // Work tree or .git directory of the git provider
+	GitHub      struct {
+		BaseURL    string `yaml:"baseURL" toml:"baseURL"`       // API root, https://api.github.com when empty
+		Repository string `yaml:"repository" toml:"repository"` // owner/repo, taken from the module path when empty
+		Token      string `yaml:"token" toml:"token"`           // Optional API token
+	} `yaml:"github" toml:"github"`
+}
+
+// Duration is a time.Duration written as a string such as "90s" or "24h".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	value, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(value)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Default returns the settings used when neither a file nor the environment sets them.
+func Default() Config {
+	charts := svggen.DefaultSettings()
+	policy := svggen.DefaultCachePolicy()
+	cfg := Config{
+		Addr:  ":8080",
+		Theme: charts.Theme,
+		Server: Server{
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			ReadTimeout:       Duration(10 * time.Second),
+			WriteTimeout:      Duration(30 * time.Second),
+			IdleTimeout:       Duration(2 * time.Minute),
+			MaxHeaderBytes:    64 << 10,
+			ShutdownTimeout:   Duration(20 * time.Second),
+		},
+		Limits: Limits{
+			MaxChartSize:     charts.MaxChartSize,
+			MaxWaffleSquares: charts.MaxWaffleSquares,
+		},
+		Cache: Cache{
+			MaxAge:          Duration(policy.MaxAge),
+			TodayMaxAge:     Duration(policy.TodayMaxAge),
+			RenderCacheSize: svggen.DefaultRenderCacheSize,
+		},
+		Features: Features{PNG: charts.PNG, Metrics: true, Version: true},
+		Version: Version{
+			TagProvider: TagProviderNone,
+			Timeout:     Duration(5 * time.Second),
+			CacheTTL:    Duration(time.Hour),
+			GitDir:      ".",
+		},
+	}
+	cfg.Charts.Bar.Width, cfg.Charts.Bar.Height = charts.Bar.Width, charts.Bar.Height
+	cfg.Charts.Circle.Size, cfg.Charts.Circle.StrokeWidth = charts.Circle.Size, charts.Circle.StrokeWidth
+	cfg.Charts.Gauge.Width = charts.Gauge.Width
+	cfg.Charts.Waffle.Width, cfg.Charts.Waffle.NumberOfSquares, cfg.Charts.Waffle.Gap = charts.Waffle.Width, charts.Waffle.NumberOfSquares, charts.Waffle.Gap
+	return cfg
+}
+
+// Load returns the default settings, overridden by the file at path when path is not
+// empty, then by the environment variables found by lookupEnv. Every setting is checked,
+// and all the problems found are reported in one error.
+func Load(path string, lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := readFile(path, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+	if err := applyEnv(reflect.ValueOf(&cfg).Elem(), EnvPrefix, lookupEnv); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// readFile decodes a .yaml, .yml or .toml file into cfg. Keys that are not settings are
+// rejected, so a typo does not silently leave a default in place.
+func readFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalWithOptions(data, cfg, yaml.DisallowUnknownField())
+	case ".toml":
+		err = toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields().Decode(cfg)
+		var strictErr *toml.StrictMissingError
+		if errors.As(err, &strictErr) {
+			keys := make([]string, len(strictErr.Errors))
+			for i, keyErr := range strictErr.Errors {
+				keys[i] = strings.Join(keyErr.Key(), ".")
+			}
+			err = fmt.Errorf("unknown keys %s", strings.Join(keys, ", "))
+		}
+	default:
+		return fmt.Errorf("%s: unsupported config file type %q (must be .yaml, .yml or .toml)", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv sets each field of v from the environment variable named after its key.
+func applyEnv(v reflect.Value, prefix string, lookupEnv func(string) (string, bool)) error {
+	var errs []error
+	for i := 0; i < v.NumField(); i++ {
+		field, value := v.Type().Field(i), v.Field(i)
+		name := prefix + envName(strings.Split(field.Tag.Get("yaml"), ",")[0])
+		if field.Type.Kind() == reflect.Struct {
+			errs = append(errs, applyEnv(value, name+"_", lookupEnv))
+			continue
+		}
+		raw, ok := lookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setValue(value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func setValue(value reflect.Value, raw string) error {
+	if unmarshaler, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(raw))
+	}
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid whole number %q", raw)
+		}
+		value.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		value.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported setting type %s", value.Type())
+	}
+	return nil
+}
+
+// envName converts a camel case key such as maxChartSize to MAX_CHART_SIZE.
+// Acronyms stay together, so cacheTTL becomes CACHE_TTL.
+func envName(key string) string {
+	var b strings.Builder
+	runes := []rune(key)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			afterLower := unicode.IsLower(runes[i-1])
+			endsAcronym := i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])
+			if afterLower || endsAcronym {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// Validate checks every setting and reports all the problems found, each naming its key.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	checkRange := func(key string, value, min, max int) {
+		check(value >= min && value <= max, "%s must be between %d and %d, got %d", key, min, max, value)
+	}
+
+	_, _, err := net.SplitHostPort(c.Addr)
+	check(err == nil, "addr must be a host:port address such as :8080, got %q", c.Addr)
+	check(slices.Contains(svggen.Themes(), c.Theme), "theme must be one of %s, got %q", strings.Join(svggen.Themes(), ", "), c.Theme)
+
+	checkTimeout := func(key string, timeout Duration) {
+		check(timeout > 0, "%s must be positive, got %s", key, time.Duration(timeout))
+	}
+	checkTimeout("server.readHeaderTimeout", c.Server.ReadHeaderTimeout)
+	checkTimeout("server.readTimeout", c.Server.ReadTimeout)
+	checkTimeout("server.writeTimeout", c.Server.WriteTimeout)
+	checkTimeout("server.idleTimeout", c.Server.IdleTimeout)
+	checkTimeout("server.shutdownTimeout", c.Server.ShutdownTimeout)
+	check(c.Server.MaxHeaderBytes >= 1<<10, "server.maxHeaderBytes must be at least 1024, got %d", c.Server.MaxHeaderBytes)
+
+	tagProviders := []string{TagProviderNone, TagProviderStatic, TagProviderGit, TagProviderGitHub}
+	check(slices.Contains(tagProviders, c.Version.TagProvider), "version.tagProvider must be one of %s, got %q", strings.Join(tagProviders, ", "), c.Version.TagProvider)
+	checkTimeout("version.timeout", c.Version.Timeout)
+	check(c.Version.CacheTTL >= 0, "version.cacheTTL must not be negative, got %s", time.Duration(c.Version.CacheTTL))
+	if repository := c.Version.GitHub.Repository; repository != "" {
+		owner, repo, ok := strings.Cut(repository, "/")
+		check(ok && owner != "" && repo != "" && !strings.Contains(repo, "/"), "version.github.repository must be owner/repo, got %q", repository)
+	}
+
+	check(c.Limits.MaxChartSize >= 1, "limits.maxChartSize must be at least 1, got %d", c.Limits.MaxChartSize)
+	check(c.Limits.MaxWaffleSquares >= 1, "limits.maxWaffleSquares must be at least 1, got %d", c.Limits.MaxWaffleSquares)
+
+	check(c.Cache.MaxAge >= 0, "cache.maxAge must not be negative, got %s", time.Duration(c.Cache.MaxAge))
+	check(c.Cache.TodayMaxAge >= 0, "cache.todayMaxAge must not be negative, got %s", time.Duration(c.Cache.TodayMaxAge))
+	check(c.Cache.RenderCacheSize >= 0, "cache.renderCacheSize must not be negative, got %d", c.Cache.RenderCacheSize)
+
+	maxSize := max(c.Limits.MaxChartSize, 1)
+	checkRange("charts.bar.width", c.Charts.Bar.Width, 1, maxSize)
+	checkRange("charts.bar.height", c.Charts.Bar.Height, 1, maxSize)
+	checkRange("charts.circle.size", c.Charts.Circle.Size, 1, maxSize)
+	checkRange("charts.circle.strokeWidth", c.Charts.Circle.StrokeWidth, 1, max(c.Charts.Circle.Size/2-1, 1))
+	checkRange("charts.gauge.width", c.Charts.Gauge.Width, 1, maxSize)
+	checkRange("charts.waffle.width", c.Charts.Waffle.Width, 10, max(maxSize, 10))
+	checkRange("charts.waffle.numberOfSquares", c.Charts.Waffle.NumberOfSquares, 1, max(c.Limits.MaxWaffleSquares, 1))
+	check(c.Charts.Waffle.Gap >= 1, "charts.waffle.gap must be at least 1, got %d", c.Charts.Waffle.Gap)
+
+	return errors.Join(errs...)
+}
+
+// ChartSettings returns the settings of chart requests.
+func (c Config) ChartSettings() svggen.Settings {
+	s := svggen.DefaultSettings()
+	s.Theme = c.Theme
+	s.MaxChartSize, s.MaxWaffleSquares = c.Limits.MaxChartSize, c.Limits.MaxWaffleSquares
+	s.PNG = c.Features.PNG
+	s.Bar.Width, s.Bar.Height = c.Charts.Bar.Width, c.Charts.Bar.Height
+	s.Circle.Size, s.Circle.StrokeWidth = c.Charts.Circle.Size, c.Charts.Circle.StrokeWidth
+	s.Gauge.Width = c.Charts.Gauge.Width
+	s.Waffle.Width, s.Waffle.NumberOfSquares, s.Waffle.Gap = c.Charts.Waffle.Width, c.Charts.Waffle.NumberOfSquares, c.Charts.Waffle.Gap
+	return s
+}
+
+// CachePolicy returns the Cache-Control policy of chart responses.
+func (c Config) CachePolicy() svggen.CachePolicy {
+	return svggen.CachePolicy{MaxAge: time.Duration(c.Cache.MaxAge), TodayMaxAge: time.Duration(c.Cache.TodayMaxAge)}
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func env(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+func TestLoad(t *testing.T) {
+	yamlFile := writeFile(t, "dre.yaml", "addr: 127.0.0.1:9000\ntheme: dark\ncache:\n  maxAge: 1h\ncharts:\n  bar:\n    width: 321\nfeatures:\n  png: false\n")
+	tomlFile := writeFile(t, "dre.toml", "addr = \"127.0.0.1:9000\"\ntheme = \"dark\"\n[cache]\nmaxAge = \"1h\"\n[charts.bar]\nwidth = 321\n[features]\npng = false\n")
+
+	testCases := []struct {
+		name string
+		path string
+		env  map[string]string
+	}{
+		{"YAML", yamlFile, nil},
+		{"TOML", tomlFile, nil},
+		{"Environment", "", map[string]string{
+			"DRE_ADDR":              "127.0.0.1:9000",
+			"DRE_THEME":             "dark",
+			"DRE_CACHE_MAX_AGE":     "1h",
+			"DRE_CHARTS_BAR_WIDTH":  "321",
+			"DRE_FEATURES_PNG":      "false",
+			"DRE_UNRELATED_SETTING": "ignored",
+		}},
+		{"Environment overrides the file", yamlFile, map[string]string{"DRE_CHARTS_BAR_WIDTH": "321"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := Load(tc.path, env(tc.env))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if cfg.Addr != "127.0.0.1:9000" || cfg.Theme != "dark" || cfg.Charts.Bar.Width != 321 || cfg.Features.PNG {
+				t.Errorf("Expected the settings to be loaded, got %+v", cfg)
+			}
+			if time.Duration(cfg.Cache.MaxAge) != time.Hour {
+				t.Errorf("Expected a max-age of 1h, got %s", time.Duration(cfg.Cache.MaxAge))
+			}
+			// Settings left out keep their defaults
+			if cfg.Charts.Bar.Height != Default().Charts.Bar.Height || !cfg.Features.Metrics {
+				t.Errorf("Expected defaults for unset settings, got %+v", cfg)
+			}
+			if settings := cfg.ChartSettings(); settings.Bar.Width != 321 || settings.Theme != "dark" || settings.PNG {
+				t.Errorf("Expected the chart settings to follow the config, got %+v", settings)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		path          string
+		env           map[string]string
+		expectedInErr []string
+	}{
+		{"Unknown key", writeFile(t, "dre.yaml", "charts:\n  circl:\n    size: 3\n"), nil, []string{"unknown field", "circl"}},
+		{"Unknown TOML key", writeFile(t, "dre.toml", "[charts.circl]\nsize = 3\n"), nil, []string{"circl"}},
+		{"Unsupported file type", writeFile(t, "dre.json", "{}"), nil, []string{"unsupported config file type"}},
+		{"Missing file", filepath.Join(t.TempDir(), "missing.yaml"), nil, []string{"missing.yaml"}},
+		{"Invalid environment value", "", map[string]string{"DRE_LIMITS_MAX_CHART_SIZE": "big", "DRE_FEATURES_METRICS": "maybe"}, []string{
+			`DRE_LIMITS_MAX_CHART_SIZE: invalid whole number "big"`,
+			`DRE_FEATURES_METRICS: invalid boolean "maybe"`,
+		}},
+		{"Invalid duration", "", map[string]string{"DRE_CACHE_TODAY_MAX_AGE": "soon"}, []string{"DRE_CACHE_TODAY_MAX_AGE"}},
+		{"Every invalid setting", "", map[string]string{
+			"DRE_ADDR":                            "8080",
+			"DRE_THEME":                           "neon",
+			"DRE_LIMITS_MAX_CHART_SIZE":           "500",
+			"DRE_CHARTS_BAR_WIDTH":                "501",
+			"DRE_CHARTS_WAFFLE_GAP":               "0",
+			"DRE_CACHE_RENDER_CACHE_SIZE":         "-1",
+			"DRE_CHARTS_CIRCLE_STROKE_WIDTH":      "60",
+			"DRE_CHARTS_WAFFLE_NUMBER_OF_SQUARES": "0",
+			"DRE_SERVER_WRITE_TIMEOUT":            "0s",
+			"DRE_SERVER_MAX_HEADER_BYTES":         "10",
+			"DRE_VERSION_TAG_PROVIDER":            "gitlab",
+			"DRE_VERSION_GITHUB_REPOSITORY":       "owner",
+		}, []string{
+			`addr must be a host:port address such as :8080, got "8080"`,
+			`theme must be one of`,
+			"charts.bar.width must be between 1 and 500, got 501",
+			"charts.waffle.gap must be at least 1, got 0",
+			"cache.renderCacheSize must not be negative, got -1",
+			"charts.circle.strokeWidth must be between 1 and 49, got 60",
+			"charts.waffle.numberOfSquares must be between 1 and 10000, got 0",
+			"server.writeTimeout must be positive, got 0s",
+			"server.maxHeaderBytes must be at least 1024, got 10",
+			`version.tagProvider must be one of none, static, git, github, got "gitlab"`,
+			`version.github.repository must be owner/repo, got "owner"`,
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(tc.path, env(tc.env))
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			for _, expected := range tc.expectedInErr {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("Expected error to contain %q, got %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Errorf("Expected the defaults to be valid, got %v", err)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	testCases := []struct {
+		key      string
+		expected string
+	}{
+		{"addr", "ADDR"},
+		{"maxChartSize", "MAX_CHART_SIZE"},
+		{"png", "PNG"},
+		{"cacheTTL", "CACHE_TTL"},
+		{"baseURL", "BASE_URL"},
+		{"tagProvider", "TAG_PROVIDER"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			if actual := envName(tc.key); actual != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, actual)
+			}
+		})
+	}
+}
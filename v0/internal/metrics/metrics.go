@@ -0,0 +1,198 @@
+// Package metrics exposes the server's request, render and cache statistics
+// in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/svggen"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType is the media type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// unmatchedRoute labels requests that did not match any route, so scanners
+// probing random paths cannot create a series per path.
+const unmatchedRoute = "unmatched"
+
+// DefaultBuckets are the upper bounds, in seconds, of the request latency histogram.
+// They match the defaults of the Prometheus client libraries.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type requestKey struct {
+	Method, Route, Code string
+}
+
+type routeKey struct {
+	Method, Route string
+}
+
+type renderErrorKey struct {
+	Route, Reason string
+}
+
+type histogram struct {
+	counts []uint64 // Observations per bucket, not cumulative; the last one is +Inf
+	sum    float64
+	count  uint64
+}
+
+// Metrics collects the statistics of one router. Use Middleware to record requests
+// and Handle to serve them.
+type Metrics struct {
+	mu           sync.Mutex
+	buckets      []float64
+	requests     map[requestKey]uint64
+	latencies    map[routeKey]*histogram
+	renderErrors map[renderErrorKey]uint64
+	version      string // Labels of dre_build_info
+	revision     string
+}
+
+// New returns an empty Metrics using DefaultBuckets. The version and revision label
+// dre_build_info and should be those /version reports, including values set with -ldflags.
+func New(version, revision string) *Metrics {
+	return &Metrics{
+		version:      version,
+		revision:     revision,
+		buckets:      DefaultBuckets,
+		requests:     map[requestKey]uint64{},
+		latencies:    map[routeKey]*histogram{},
+		renderErrors: map[renderErrorKey]uint64{},
+	}
+}
+
+// Middleware records the count and latency of every request by method, route and status,
+// and the charts that could not be rendered.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		reason := c.GetString(svggen.RenderErrorKey)
+		m.observe(c.Request.Method, route, c.Writer.Status(), time.Since(start), reason)
+	}
+}
+
+func (m *Metrics) observe(method, route string, status int, elapsed time.Duration, renderError string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestKey{method, route, strconv.Itoa(status)}]++
+
+	h, ok := m.latencies[routeKey{method, route}]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets)+1)}
+		m.latencies[routeKey{method, route}] = h
+	}
+	seconds := elapsed.Seconds()
+	h.counts[sort.SearchFloat64s(m.buckets, seconds)]++
+	h.sum += seconds
+	h.count++
+
+	if renderError != "" {
+		m.renderErrors[renderErrorKey{route, renderError}]++
+	}
+}
+
+// Handle serves the metrics in the Prometheus text exposition format.
+func (m *Metrics) Handle(c *gin.Context) {
+	var buf bytes.Buffer
+	m.WriteTo(&buf)
+	c.Data(http.StatusOK, ContentType, buf.Bytes())
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+// Series are sorted so the output is stable between scrapes.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+	m.mu.Lock()
+
+	writeHeader(&b, "dre_http_requests_total", "counter", "Requests handled, by method, route and status code.")
+	for _, key := range sortedKeys(m.requests, func(k requestKey) string { return k.Method + " " + k.Route + " " + k.Code }) {
+		writeSample(&b, "dre_http_requests_total", m.requests[key], "method", key.Method, "route", key.Route, "code", key.Code)
+	}
+
+	writeHeader(&b, "dre_http_request_duration_seconds", "histogram", "Time taken to handle a request, by method and route.")
+	for _, key := range sortedKeys(m.latencies, func(k routeKey) string { return k.Method + " " + k.Route }) {
+		h := m.latencies[key]
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += h.counts[i]
+			writeSample(&b, "dre_http_request_duration_seconds_bucket", cumulative, "method", key.Method, "route", key.Route, "le", formatFloat(bound))
+		}
+		writeSample(&b, "dre_http_request_duration_seconds_bucket", h.count, "method", key.Method, "route", key.Route, "le", "+Inf")
+		writeSample(&b, "dre_http_request_duration_seconds_sum", h.sum, "method", key.Method, "route", key.Route)
+		writeSample(&b, "dre_http_request_duration_seconds_count", h.count, "method", key.Method, "route", key.Route)
+	}
+
+	writeHeader(&b, "dre_render_errors_total", "counter", "Charts that could not be rendered, by route and reason (params or internal).")
+	for _, key := range sortedKeys(m.renderErrors, func(k renderErrorKey) string { return k.Route + " " + k.Reason }) {
+		writeSample(&b, "dre_render_errors_total", m.renderErrors[key], "route", key.Route, "reason", key.Reason)
+	}
+	m.mu.Unlock()
+
+	cache := svggen.RenderCache()
+	writeHeader(&b, "dre_render_cache_hits_total", "counter", "Charts served from the render cache.")
+	writeSample(&b, "dre_render_cache_hits_total", cache.Hits)
+	writeHeader(&b, "dre_render_cache_misses_total", "counter", "Charts that had to be rendered.")
+	writeSample(&b, "dre_render_cache_misses_total", cache.Misses)
+	writeHeader(&b, "dre_render_cache_entries", "gauge", "Charts held in the render cache.")
+	writeSample(&b, "dre_render_cache_entries", cache.Entries)
+	writeHeader(&b, "dre_render_cache_size", "gauge", "Largest number of charts the render cache holds.")
+	writeSample(&b, "dre_render_cache_size", cache.Size)
+
+	writeHeader(&b, "dre_build_info", "gauge", "Always 1, labeled with the version and revision of the running binary.")
+	writeSample(&b, "dre_build_info", 1, "version", m.version, "revision", m.revision, "goversion", runtime.Version())
+
+	return b.WriteTo(w)
+}
+
+func writeHeader(b *bytes.Buffer, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writeSample writes one sample line. labels alternate between names and values.
+func writeSample[V uint64 | int | float64](b *bytes.Buffer, name string, value V, labels ...string) {
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", labels[i], labelEscaper.Replace(labels[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(formatFloat(float64(value)))
+	b.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// sortedKeys returns the keys of m ordered by the string form given by name.
+func sortedKeys[K comparable, V any](m map[K]V, name func(K) string) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return name(keys[i]) < name(keys[j]) })
+	return keys
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/svggen"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandle(t *testing.T) {
+	stats := New("v1.4.2", "0123456789abcdef")
+	router := gin.New()
+	router.Use(stats.Middleware())
+	router.GET("/progress/bar", svggen.HandleProgressBar)
+	router.GET("/metrics", stats.Handle)
+
+	for _, queryString := range []string{
+		"/progress/bar?percentage=50",
+		"/progress/bar?percentage=50",
+		"/progress/bar?percentage=abc",
+		"/progress/bar?percentage=abc&onError=badge",
+		"/nowhere",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", queryString, nil)
+		router.ServeHTTP(w, req)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != ContentType {
+		t.Errorf("Expected Content-Type %q, got %q", ContentType, contentType)
+	}
+
+	body := w.Body.String()
+	expectedInBody := []string{
+		"# TYPE dre_http_requests_total counter\n",
+		`dre_http_requests_total{method="GET",route="/progress/bar",code="200"} 3` + "\n",
+		`dre_http_requests_total{method="GET",route="/progress/bar",code="400"} 1` + "\n",
+		`dre_http_requests_total{method="GET",route="unmatched",code="404"} 1` + "\n",
+		"# TYPE dre_http_request_duration_seconds histogram\n",
+		`dre_http_request_duration_seconds_bucket{method="GET",route="/progress/bar",le="+Inf"} 4` + "\n",
+		`dre_http_request_duration_seconds_count{method="GET",route="/progress/bar"} 4` + "\n",
+		`dre_render_errors_total{route="/progress/bar",reason="params"} 2` + "\n",
+		"# TYPE dre_render_cache_hits_total counter\n",
+		"# TYPE dre_render_cache_misses_total counter\n",
+		`dre_build_info{version="v1.4.2",revision="0123456789abcdef",goversion=`,
+	}
+	for _, expected := range expectedInBody {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected body to contain %q, got:\n%s", expected, body)
+		}
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	stats := New("v1.4.2", "0123456789abcdef")
+	stats.observe("GET", "/health", http.StatusOK, 3*time.Millisecond, "")
+	stats.observe("GET", "/health", http.StatusOK, 30*time.Millisecond, "")
+	stats.observe("GET", "/health", http.StatusOK, time.Minute, "")
+
+	var b strings.Builder
+	if _, err := stats.WriteTo(&b); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testCases := []struct {
+		le       string
+		expected string
+	}{
+		{"0.005", "1"},
+		{"0.025", "1"},
+		{"0.05", "2"},
+		{"10", "2"},
+		{"+Inf", "3"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.le, func(t *testing.T) {
+			line := `dre_http_request_duration_seconds_bucket{method="GET",route="/health",le="` + tc.le + `"} ` + tc.expected + "\n"
+			if !strings.Contains(b.String(), line) {
+				t.Errorf("Expected %q, got:\n%s", line, b.String())
+			}
+		})
+	}
+}
+
+func TestLabelEscaping(t *testing.T) {
+	stats := New("v1.4.2", "0123456789abcdef")
+	stats.observe("GET", "/a\"b\\c\nd", http.StatusOK, time.Millisecond, "")
+
+	var b strings.Builder
+	stats.WriteTo(&b)
+	expected := `route="/a\"b\\c\nd"`
+	if !strings.Contains(b.String(), expected) {
+		t.Errorf("Expected %q, got:\n%s", expected, b.String())
+	}
+}
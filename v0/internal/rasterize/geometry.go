@@ -0,0 +1,578 @@
+package rasterize
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type point struct {
+	x, y float64
+}
+
+// matrix is an affine transform [a b c d e f], mapping (x, y) to (a*x + c*y + e, b*x + d*y + f).
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// mul returns the transform that applies n first and then m.
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+func (m matrix) apply(p point) point {
+	return point{m[0]*p.x + m[2]*p.y + m[4], m[1]*p.x + m[3]*p.y + m[5]}
+}
+
+func (m matrix) applyAll(points []point) []point {
+	result := make([]point, len(points))
+	for i, p := range points {
+		result[i] = m.apply(p)
+	}
+	return result
+}
+
+// scale returns the average factor by which m scales lengths.
+func (m matrix) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+var transformRegex = regexp.MustCompile(`(\w+)\s*\(([^)]*)\)`)
+
+// parseTransform parses the translate, scale, rotate and matrix functions of a transform attribute.
+// Unsupported functions are ignored.
+func parseTransform(s string) matrix {
+	m := identity
+	for _, match := range transformRegex.FindAllStringSubmatch(s, -1) {
+		args := parseNumberList(match[2])
+		arg := func(i int, fallback float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return fallback
+		}
+		var t matrix
+		switch match[1] {
+		case "translate":
+			t = matrix{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			sx := arg(0, 1)
+			t = matrix{sx, 0, 0, arg(1, sx), 0, 0}
+		case "rotate":
+			rad := arg(0, 0) * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			cos, sin := math.Cos(rad), math.Sin(rad)
+			t = matrix{1, 0, 0, 1, cx, cy}.
+				mul(matrix{cos, sin, -sin, cos, 0, 0}).
+				mul(matrix{1, 0, 0, 1, -cx, -cy})
+		case "matrix":
+			if len(args) != 6 {
+				continue
+			}
+			copy(t[:], args)
+		default:
+			continue
+		}
+		m = m.mul(t)
+	}
+	return m
+}
+
+var numberRegex = regexp.MustCompile(`[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`)
+
+// parseNumberList returns all numbers in a comma or whitespace separated list.
+func parseNumberList(s string) []float64 {
+	var numbers []float64
+	for _, match := range numberRegex.FindAllString(s, -1) {
+		if v, err := strconv.ParseFloat(match, 64); err == nil {
+			numbers = append(numbers, v)
+		}
+	}
+	return numbers
+}
+
+// parseNumber parses a plain number, returning 0 if it is invalid.
+func parseNumber(s string) float64 {
+	v, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return v
+}
+
+// parseLength parses a length in user units, accepting an optional "px" suffix.
+func parseLength(s string) float64 {
+	return parseNumber(strings.TrimSuffix(strings.TrimSpace(s), "px"))
+}
+
+// shape converts a basic shape or path element into subpaths in user space.
+// Returns nil for elements that are not shapes. closed reports whether the outline
+// should be stroked as a closed loop.
+func shape(el *element) (subpaths [][]point, closed bool) {
+	a := func(name string) float64 { return parseLength(el.attrs[name]) }
+	switch el.name {
+	case "rect":
+		x, y, w, h := a("x"), a("y"), a("width"), a("height")
+		if w <= 0 || h <= 0 {
+			return [][]point{}, true
+		}
+		rx, rxOK := el.attrs["rx"]
+		ry, ryOK := el.attrs["ry"]
+		if !rxOK {
+			rx = ry
+		}
+		if !ryOK {
+			ry = rx
+		}
+		return [][]point{roundedRect(x, y, w, h, parseLength(rx), parseLength(ry))}, true
+	case "circle":
+		r := a("r")
+		return [][]point{ellipse(a("cx"), a("cy"), r, r)}, true
+	case "ellipse":
+		return [][]point{ellipse(a("cx"), a("cy"), a("rx"), a("ry"))}, true
+	case "line":
+		return [][]point{{{a("x1"), a("y1")}, {a("x2"), a("y2")}}}, false
+	case "polyline", "polygon":
+		values := parseNumberList(el.attrs["points"])
+		var points []point
+		for i := 0; i+1 < len(values); i += 2 {
+			points = append(points, point{values[i], values[i+1]})
+		}
+		return [][]point{points}, el.name == "polygon"
+	case "path":
+		return parsePath(el.attrs["d"])
+	}
+	return nil, false
+}
+
+// roundedRect returns the outline of a rectangle with elliptical corners.
+func roundedRect(x, y, w, h, rx, ry float64) []point {
+	rx = math.Min(math.Max(rx, 0), w/2)
+	ry = math.Min(math.Max(ry, 0), h/2)
+	if rx == 0 || ry == 0 {
+		return []point{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}
+	}
+	var points []point
+	points = appendArc(points, x+w-rx, y+ry, rx, ry, -math.Pi/2, 0)
+	points = appendArc(points, x+w-rx, y+h-ry, rx, ry, 0, math.Pi/2)
+	points = appendArc(points, x+rx, y+h-ry, rx, ry, math.Pi/2, math.Pi)
+	points = appendArc(points, x+rx, y+ry, rx, ry, math.Pi, 3*math.Pi/2)
+	return points
+}
+
+// ellipse returns the outline of an ellipse starting at its rightmost point, which is
+// where SVG starts dashing a circle.
+func ellipse(cx, cy, rx, ry float64) []point {
+	if rx <= 0 || ry <= 0 {
+		return nil
+	}
+	points := appendArc(nil, cx, cy, rx, ry, 0, 2*math.Pi)
+	return points[:len(points)-1] // The last point repeats the first
+}
+
+// appendArc appends points along an axis aligned elliptical arc from angle a0 to a1.
+func appendArc(points []point, cx, cy, rx, ry, a0, a1 float64) []point {
+	segments := arcSegments(math.Max(rx, ry), a1-a0)
+	for i := 0; i <= segments; i++ {
+		angle := a0 + (a1-a0)*float64(i)/float64(segments)
+		points = append(points, point{cx + rx*math.Cos(angle), cy + ry*math.Sin(angle)})
+	}
+	return points
+}
+
+// arcSegments returns how many line segments approximate an arc closely enough.
+func arcSegments(radius, sweep float64) int {
+	segments := int(math.Ceil(math.Abs(sweep) * math.Max(radius, 1) / 2))
+	if segments < 8 {
+		segments = 8
+	}
+	if segments > 720 {
+		segments = 720
+	}
+	return segments
+}
+
+var pathTokenRegex = regexp.MustCompile(`[MmLlHhVvCcSsQqTtAaZz]|[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`)
+
+// parsePath flattens SVG path data into subpaths of line segments.
+// Returns closed as true if any subpath ends with a Z command.
+func parsePath(d string) (subpaths [][]point, closed bool) {
+	tokens := pathTokenRegex.FindAllString(d, -1)
+	var current []point
+	var pos, start, lastControl point
+	var command byte
+	i := 0
+
+	next := func() (float64, bool) {
+		if i >= len(tokens) || isPathCommand(tokens[i]) {
+			return 0, false
+		}
+		v, err := strconv.ParseFloat(tokens[i], 64)
+		i++
+		return v, err == nil
+	}
+	flush := func() {
+		if len(current) > 1 {
+			subpaths = append(subpaths, current)
+		}
+		current = nil
+	}
+
+	for i < len(tokens) {
+		if isPathCommand(tokens[i]) {
+			command = tokens[i][0]
+			i++
+			if command == 'Z' || command == 'z' {
+				closed = true
+				pos = start
+				flush()
+				continue
+			}
+		} else if command == 0 {
+			break // Numbers before the first command
+		}
+
+		relative := command >= 'a'
+		offset := func(p point) point {
+			if relative {
+				return point{p.x + pos.x, p.y + pos.y}
+			}
+			return p
+		}
+
+		var ok bool
+		switch command {
+		case 'M', 'm':
+			var x, y float64
+			if x, ok = next(); !ok {
+				break
+			}
+			if y, ok = next(); !ok {
+				break
+			}
+			flush()
+			pos = offset(point{x, y})
+			start = pos
+			current = []point{pos}
+			// Further coordinate pairs are implicit line commands
+			if relative {
+				command = 'l'
+			} else {
+				command = 'L'
+			}
+		case 'L', 'l':
+			var x, y float64
+			if x, ok = next(); !ok {
+				break
+			}
+			if y, ok = next(); !ok {
+				break
+			}
+			pos = offset(point{x, y})
+			current = append(current, pos)
+		case 'H', 'h':
+			var x float64
+			if x, ok = next(); !ok {
+				break
+			}
+			if relative {
+				x += pos.x
+			}
+			pos = point{x, pos.y}
+			current = append(current, pos)
+		case 'V', 'v':
+			var y float64
+			if y, ok = next(); !ok {
+				break
+			}
+			if relative {
+				y += pos.y
+			}
+			pos = point{pos.x, y}
+			current = append(current, pos)
+		case 'C', 'c', 'S', 's':
+			var values []float64
+			count := 6
+			if command == 'S' || command == 's' {
+				count = 4
+			}
+			for j := 0; j < count; j++ {
+				var v float64
+				if v, ok = next(); !ok {
+					break
+				}
+				values = append(values, v)
+			}
+			if !ok {
+				break
+			}
+			c1 := point{2*pos.x - lastControl.x, 2*pos.y - lastControl.y}
+			if count == 6 {
+				c1 = offset(point{values[0], values[1]})
+				values = values[2:]
+			}
+			c2 := offset(point{values[0], values[1]})
+			end := offset(point{values[2], values[3]})
+			current = appendCubic(current, pos, c1, c2, end)
+			pos, lastControl = end, c2
+			continue
+		case 'Q', 'q':
+			var values [4]float64
+			for j := range values {
+				if values[j], ok = next(); !ok {
+					break
+				}
+			}
+			if !ok {
+				break
+			}
+			control := offset(point{values[0], values[1]})
+			end := offset(point{values[2], values[3]})
+			current = appendQuadratic(current, pos, control, end)
+			pos, lastControl = end, control
+			continue
+		case 'A', 'a':
+			var values [7]float64
+			for j := range values {
+				if values[j], ok = next(); !ok {
+					break
+				}
+			}
+			if !ok {
+				break
+			}
+			end := offset(point{values[5], values[6]})
+			current = appendEndpointArc(current, pos, end, values[0], values[1], values[2], values[3] != 0, values[4] != 0)
+			pos = end
+		default:
+			ok = false
+		}
+		if !ok {
+			break // Malformed data: keep what was parsed so far, like browsers do
+		}
+		lastControl = pos
+	}
+	flush()
+	return subpaths, closed
+}
+
+func isPathCommand(token string) bool {
+	return strings.ContainsAny(token[:1], "MmLlHhVvCcSsQqTtAaZz")
+}
+
+func appendCubic(points []point, p0, p1, p2, p3 point) []point {
+	const steps = 24
+	for i := 1; i <= steps; i++ {
+		t := float64(i) / steps
+		u := 1 - t
+		points = append(points, point{
+			u*u*u*p0.x + 3*u*u*t*p1.x + 3*u*t*t*p2.x + t*t*t*p3.x,
+			u*u*u*p0.y + 3*u*u*t*p1.y + 3*u*t*t*p2.y + t*t*t*p3.y,
+		})
+	}
+	return points
+}
+
+func appendQuadratic(points []point, p0, p1, p2 point) []point {
+	const steps = 16
+	for i := 1; i <= steps; i++ {
+		t := float64(i) / steps
+		u := 1 - t
+		points = append(points, point{
+			u*u*p0.x + 2*u*t*p1.x + t*t*p2.x,
+			u*u*p0.y + 2*u*t*p1.y + t*t*p2.y,
+		})
+	}
+	return points
+}
+
+// appendEndpointArc appends an SVG arc command, converting the endpoint parameterization
+// to a center parameterization as described in the SVG specification, appendix F.6.5.
+func appendEndpointArc(points []point, from, to point, rx, ry, rotation float64, largeArc, sweep bool) []point {
+	if from == to {
+		return points
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		return append(points, to)
+	}
+
+	phi := rotation * math.Pi / 180
+	cosPhi, sinPhi := math.Cos(phi), math.Sin(phi)
+	dx, dy := (from.x-to.x)/2, (from.y-to.y)/2
+	x1 := cosPhi*dx + sinPhi*dy
+	y1 := -sinPhi*dx + cosPhi*dy
+
+	// Scale up radii that are too small to reach the end point
+	if lambda := x1*x1/(rx*rx) + y1*y1/(ry*ry); lambda > 1 {
+		rx *= math.Sqrt(lambda)
+		ry *= math.Sqrt(lambda)
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if largeArc == sweep {
+		coef = -coef
+	}
+	cx1 := coef * rx * y1 / ry
+	cy1 := -coef * ry * x1 / rx
+	cx := cosPhi*cx1 - sinPhi*cy1 + (from.x+to.x)/2
+	cy := sinPhi*cx1 + cosPhi*cy1 + (from.y+to.y)/2
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := angle(1, 0, (x1-cx1)/rx, (y1-cy1)/ry)
+	delta := angle((x1-cx1)/rx, (y1-cy1)/ry, (-x1-cx1)/rx, (-y1-cy1)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	segments := arcSegments(math.Max(rx, ry), delta)
+	for i := 1; i <= segments; i++ {
+		a := theta + delta*float64(i)/float64(segments)
+		x, y := rx*math.Cos(a), ry*math.Sin(a)
+		points = append(points, point{cosPhi*x - sinPhi*y + cx, sinPhi*x + cosPhi*y + cy})
+	}
+	points[len(points)-1] = to
+	return points
+}
+
+// strokeOutline returns polygons covering a stroke of the given width along the polyline.
+// Segments get butt ends and interior vertices get round joins.
+func strokeOutline(line []point, closed bool, width float64) [][]point {
+	half := width / 2
+	var polygons [][]point
+	n := len(line)
+	segments := n - 1
+	if closed {
+		segments = n
+	}
+	for i := 0; i < segments; i++ {
+		p0, p1 := line[i], line[(i+1)%n]
+		dx, dy := p1.x-p0.x, p1.y-p0.y
+		length := math.Hypot(dx, dy)
+		if length == 0 {
+			continue
+		}
+		nx, ny := -dy/length*half, dx/length*half
+		polygons = append(polygons, []point{
+			{p0.x + nx, p0.y + ny}, {p1.x + nx, p1.y + ny},
+			{p1.x - nx, p1.y - ny}, {p0.x - nx, p0.y - ny},
+		})
+	}
+	for i, p := range line {
+		if !closed && (i == 0 || i == n-1) {
+			continue
+		}
+		polygons = append(polygons, ellipse(p.x, p.y, half, half))
+	}
+	return polygons
+}
+
+// dash splits a polyline into the dashes described by a stroke-dasharray.
+// Returns ErrTooComplex if the pattern would split the line into more than limit dashes.
+func dash(line []point, pattern []float64, offset float64, limit int) ([][]point, error) {
+	if len(pattern)%2 == 1 {
+		pattern = append(pattern, pattern...)
+	}
+	total := 0.0
+	for _, v := range pattern {
+		if v < 0 {
+			return [][]point{line}, nil
+		}
+		total += v
+	}
+	if total <= 0 {
+		return [][]point{line}, nil
+	}
+	length := 0.0
+	for i := 0; i+1 < len(line); i++ {
+		length += math.Hypot(line[i+1].x-line[i].x, line[i+1].y-line[i].y)
+	}
+	// Every repetition of the pattern, including a partial one at each end, adds its dashes
+	if count := (math.Ceil(length/total) + 1) * float64(len(pattern)/2); count > float64(limit) {
+		return nil, fmt.Errorf("%w: more than %d dashes", ErrTooComplex, limit)
+	}
+
+	// Find where the pattern starts given the offset
+	index := 0
+	remaining := pattern[0]
+	offset = math.Mod(offset, total)
+	if offset < 0 {
+		offset += total
+	}
+	for offset > 0 {
+		if offset < remaining {
+			remaining -= offset
+			break
+		}
+		offset -= remaining
+		index = (index + 1) % len(pattern)
+		remaining = pattern[index]
+	}
+
+	var dashes [][]point
+	var current []point
+	on := index%2 == 0
+	if on {
+		current = []point{line[0]}
+	}
+	for i := 0; i+1 < len(line); i++ {
+		p0, p1 := line[i], line[i+1]
+		length := math.Hypot(p1.x-p0.x, p1.y-p0.y)
+		pos := 0.0
+		for length-pos > remaining {
+			pos += remaining
+			t := pos / length
+			p := point{p0.x + (p1.x-p0.x)*t, p0.y + (p1.y-p0.y)*t}
+			if on {
+				current = append(current, p)
+				if pattern[index] > 0 {
+					dashes = append(dashes, current)
+				}
+				current = nil
+			} else {
+				current = []point{p}
+			}
+			on = !on
+			index = (index + 1) % len(pattern)
+			remaining = pattern[index]
+		}
+		remaining -= length - pos
+		if on {
+			current = append(current, p1)
+		}
+	}
+	if on && len(current) > 1 {
+		dashes = append(dashes, current)
+	}
+	return dashes, nil
+}
+
+// signedArea returns the area of a polygon, positive when it winds clockwise on screen.
+func signedArea(poly []point) float64 {
+	area := 0.0
+	for i := range poly {
+		p, q := poly[i], poly[(i+1)%len(poly)]
+		area += p.x*q.y - q.x*p.y
+	}
+	return area / 2
+}
+
+func reversed(poly []point) []point {
+	result := make([]point, len(poly))
+	for i, p := range poly {
+		result[len(poly)-1-i] = p
+	}
+	return result
+}
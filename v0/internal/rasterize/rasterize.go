@@ -0,0 +1,368 @@
+// Package rasterize converts the SVG produced by the chart templates into raster images.
+//
+// It is not a general purpose SVG renderer: it supports the subset of SVG the charts use
+// (rect, circle, ellipse, line, polyline, polygon, path, text and g elements with fill,
+// stroke, dashes, opacity and transforms), in pure Go so the binary can be built without cgo.
+package rasterize
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"golang.org/x/image/vector"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// MaxDimension is the largest width or height, in pixels, that will be rasterized.
+const MaxDimension = 4096
+
+// MaxWork is the largest number of pixels, summed over the bounding boxes of every
+// filled shape and stroke, that will be rasterized for one document. It bounds the
+// time spent on documents with many large or overlapping shapes.
+const MaxWork = 16 * MaxDimension * MaxDimension
+
+// MaxDashes is the largest number of dashes that stroke-dasharray patterns may split the
+// strokes of one document into.
+const MaxDashes = 100000
+
+var (
+	// ErrImageSize is returned when a document is empty or larger than MaxDimension.
+	ErrImageSize = errors.New("invalid image size")
+	// ErrTooComplex is returned when a document would take more than MaxWork to rasterize.
+	ErrTooComplex = errors.New("too complex to rasterize")
+)
+
+// element is a parsed SVG element.
+type element struct {
+	name     string
+	attrs    map[string]string
+	text     string
+	children []*element
+}
+
+// style holds the inherited presentation attributes of an element.
+type style struct {
+	fill, stroke               string
+	strokeWidth                float64
+	strokeDasharray            string
+	strokeDashoffset           float64
+	opacity                    float64
+	fontSize                   float64
+	fontWeight                 string
+	textAnchor, baseline       string
+	fillOpacity, strokeOpacity float64
+}
+
+var defaultStyle = style{
+	fill:          "black",
+	stroke:        "none",
+	strokeWidth:   1,
+	opacity:       1,
+	fontSize:      16,
+	fontWeight:    "normal",
+	textAnchor:    "start",
+	baseline:      "auto",
+	fillOpacity:   1,
+	strokeOpacity: 1,
+}
+
+// PNG rasterizes the SVG document read from r and writes it to w as a PNG.
+func PNG(w io.Writer, r io.Reader) error {
+	img, err := Image(r)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// Image rasterizes the SVG document read from r.
+// The image size is taken from the width and height attributes of the root element,
+// falling back to its viewBox.
+func Image(r io.Reader) (*image.RGBA, error) {
+	root, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if root.name != "svg" {
+		return nil, fmt.Errorf("root element is <%s>, not <svg>", root.name)
+	}
+
+	vb, hasViewBox := parseViewBox(root.attrs["viewBox"])
+	width, height := parseLength(root.attrs["width"]), parseLength(root.attrs["height"])
+	if width <= 0 && hasViewBox {
+		width = vb[2]
+	}
+	if height <= 0 && hasViewBox {
+		height = vb[3]
+	}
+	w, h := int(math.Ceil(width)), int(math.Ceil(height))
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("%w %dx%d", ErrImageSize, w, h)
+	}
+	if w > MaxDimension || h > MaxDimension {
+		return nil, fmt.Errorf("%w %dx%d: the maximum is %d pixels", ErrImageSize, w, h, MaxDimension)
+	}
+
+	m := identity
+	if hasViewBox && vb[2] > 0 && vb[3] > 0 {
+		m = matrix{width / vb[2], 0, 0, height / vb[3], -vb[0] * width / vb[2], -vb[1] * height / vb[3]}
+	}
+
+	c := &canvas{dst: image.NewRGBA(image.Rect(0, 0, w, h)), fonts: newFontCache()}
+	c.rasterizer = vector.NewRasterizer(w, h)
+	c.drawChildren(root, m, inheritStyle(root, defaultStyle))
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.dst, nil
+}
+
+// parse decodes an SVG document into an element tree.
+func parse(r io.Reader) (*element, error) {
+	decoder := xml.NewDecoder(r)
+	var stack []*element
+	var root *element
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing svg: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			el := &element{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, attr := range t.Attr {
+				el.attrs[attr.Name.Local] = attr.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, el)
+			} else if root == nil {
+				root = el
+			}
+			stack = append(stack, el)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("parsing svg: no root element")
+	}
+	return root, nil
+}
+
+// canvas is the destination image and the shared state used while drawing.
+type canvas struct {
+	dst        *image.RGBA
+	rasterizer *vector.Rasterizer
+	fonts      *fontCache
+	work       int   // Pixels rasterized so far, see MaxWork
+	dashes     int   // Dashes drawn so far, see MaxDashes
+	err        error // Set when drawing stopped early
+}
+
+func (c *canvas) drawChildren(el *element, m matrix, s style) {
+	for _, child := range el.children {
+		if c.err != nil {
+			return
+		}
+		c.draw(child, m, s)
+	}
+}
+
+// draw paints el and its children using the transform m and the inherited style s.
+func (c *canvas) draw(el *element, m matrix, s style) {
+	if el.attrs["display"] == "none" || el.attrs["visibility"] == "hidden" {
+		return
+	}
+	if transform, ok := el.attrs["transform"]; ok {
+		m = m.mul(parseTransform(transform))
+	}
+	s = inheritStyle(el, s)
+
+	switch el.name {
+	case "svg", "g", "a":
+		c.drawChildren(el, m, s)
+	case "text":
+		c.drawText(el, m, s)
+	default:
+		subpaths, closed := shape(el)
+		if subpaths == nil {
+			return // Non-graphical elements such as title, desc, style and animate
+		}
+		c.drawShape(subpaths, closed, m, s)
+	}
+}
+
+// inheritStyle applies the presentation attributes of el on top of the inherited style.
+// Opacity is not inherited in SVG, but multiplying it down the tree is a close
+// approximation for the documents rendered here.
+func inheritStyle(el *element, s style) style {
+	a := el.attrs
+	if v, ok := a["fill"]; ok {
+		s.fill = v
+	}
+	if v, ok := a["stroke"]; ok {
+		s.stroke = v
+	}
+	if v, ok := a["stroke-width"]; ok {
+		s.strokeWidth = parseLength(v)
+	}
+	if v, ok := a["stroke-dasharray"]; ok {
+		s.strokeDasharray = v
+	}
+	if v, ok := a["stroke-dashoffset"]; ok {
+		s.strokeDashoffset = parseLength(v)
+	}
+	if v, ok := a["font-size"]; ok {
+		s.fontSize = parseLength(v)
+	}
+	if v, ok := a["font-weight"]; ok {
+		s.fontWeight = v
+	}
+	if v, ok := a["text-anchor"]; ok {
+		s.textAnchor = v
+	}
+	if v, ok := a["dominant-baseline"]; ok {
+		s.baseline = v
+	}
+	if v, ok := a["fill-opacity"]; ok {
+		s.fillOpacity = parseNumber(v)
+	}
+	if v, ok := a["stroke-opacity"]; ok {
+		s.strokeOpacity = parseNumber(v)
+	}
+	if v, ok := a["opacity"]; ok {
+		s.opacity *= parseNumber(v)
+	}
+	return s
+}
+
+// drawShape fills and strokes the subpaths of a shape.
+func (c *canvas) drawShape(subpaths [][]point, closed bool, m matrix, s style) {
+	if fill, ok := parseColor(s.fill); ok {
+		var device [][]point
+		for _, sp := range subpaths {
+			device = append(device, m.applyAll(sp))
+		}
+		c.fill(device, withOpacity(fill, s.opacity*s.fillOpacity), false)
+	}
+
+	stroke, ok := parseColor(s.stroke)
+	if !ok || s.strokeWidth <= 0 {
+		return
+	}
+	dashes := parseNumberList(s.strokeDasharray)
+	var outline [][]point
+	for _, sp := range subpaths {
+		if len(dashes) == 0 {
+			outline = append(outline, strokeOutline(sp, closed, s.strokeWidth)...)
+			continue
+		}
+		if closed && len(sp) > 0 {
+			sp = append(sp[:len(sp):len(sp)], sp[0])
+		}
+		lines, err := dash(sp, dashes, s.strokeDashoffset, MaxDashes-c.dashes)
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.dashes += len(lines)
+		for _, line := range lines {
+			outline = append(outline, strokeOutline(line, false, s.strokeWidth)...)
+		}
+	}
+	for i := range outline {
+		outline[i] = m.applyAll(outline[i])
+	}
+	c.fill(outline, withOpacity(stroke, s.opacity*s.strokeOpacity), true)
+}
+
+// fill paints the polygons with a solid color. When orient is true every polygon is
+// wound the same way first, so overlapping pieces of a stroke add up instead of cancelling.
+// Only the bounding box of the polygons is rasterized, and drawing stops with
+// ErrTooComplex once the boxes add up to more than MaxWork pixels.
+func (c *canvas) fill(polygons [][]point, col color.NRGBA, orient bool) {
+	if col.A == 0 || len(polygons) == 0 {
+		return
+	}
+	b := polygonBounds(polygons).Intersect(c.dst.Bounds())
+	if b.Empty() {
+		return
+	}
+	c.work += b.Dx() * b.Dy()
+	if c.work > MaxWork {
+		c.err = fmt.Errorf("%w: more than %d pixels to draw", ErrTooComplex, MaxWork)
+		return
+	}
+	c.rasterizer.Reset(b.Dx(), b.Dy())
+	dx, dy := float64(b.Min.X), float64(b.Min.Y)
+	for _, poly := range polygons {
+		if len(poly) < 3 {
+			continue
+		}
+		if orient && signedArea(poly) < 0 {
+			poly = reversed(poly)
+		}
+		c.rasterizer.MoveTo(float32(poly[0].x-dx), float32(poly[0].y-dy))
+		for _, p := range poly[1:] {
+			c.rasterizer.LineTo(float32(p.x-dx), float32(p.y-dy))
+		}
+		c.rasterizer.ClosePath()
+	}
+	c.rasterizer.Draw(c.dst, b, image.NewUniform(col), image.Point{})
+}
+
+// polygonBounds returns the smallest pixel rectangle holding every point of the polygons.
+func polygonBounds(polygons [][]point) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, poly := range polygons {
+		if len(poly) < 3 {
+			continue
+		}
+		for _, p := range poly {
+			minX, minY = math.Min(minX, p.x), math.Min(minY, p.y)
+			maxX, maxY = math.Max(maxX, p.x), math.Max(maxY, p.y)
+		}
+	}
+	if minX > maxX || minY > maxY || math.IsNaN(minX+minY+maxX+maxY) {
+		return image.Rectangle{}
+	}
+	// Clamp before converting, the points of a huge shape do not fit in an int
+	limit := float64(2 * MaxDimension)
+	clampInt := func(v float64) int { return int(math.Max(-limit, math.Min(limit, v))) }
+	return image.Rect(clampInt(math.Floor(minX)), clampInt(math.Floor(minY)), clampInt(math.Ceil(maxX)), clampInt(math.Ceil(maxY)))
+}
+
+func withOpacity(col color.NRGBA, opacity float64) color.NRGBA {
+	col.A = uint8(math.Round(float64(col.A) * clamp01(opacity)))
+	return col
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// parseViewBox parses "min-x min-y width height".
+func parseViewBox(s string) ([4]float64, bool) {
+	var vb [4]float64
+	values := parseNumberList(s)
+	if len(values) != 4 {
+		return vb, false
+	}
+	copy(vb[:], values)
+	return vb, true
+}
@@ -0,0 +1,277 @@
+package rasterize
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestImage(t *testing.T) {
+	svg := `<svg width="40px" height="20px" viewBox="0 0 40 20" xmlns="http://www.w3.org/2000/svg">
+		<rect x="0" y="0" width="20px" height="20px" fill="#44CC11" />
+		<rect x="20" y="0" width="20" height="20" fill="red" opacity="0.5" />
+		<circle cx="30" cy="10" r="4" fill="none" stroke="black" stroke-width="2" />
+	</svg>`
+
+	img, err := Image(strings.NewReader(svg))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 40 || b.Dy() != 20 {
+		t.Fatalf("Expected a 40x20 image, got %v", b)
+	}
+
+	testCases := []struct {
+		name     string
+		x, y     int
+		expected color.NRGBA
+	}{
+		{"Opaque fill", 10, 10, color.NRGBA{R: 0x44, G: 0xCC, B: 0x11, A: 0xff}},
+		{"Half transparent fill", 25, 5, color.NRGBA{R: 0xff, A: 0x80}},
+		{"Unfilled circle center", 30, 10, color.NRGBA{R: 0xff, A: 0x80}},
+		{"Circle stroke", 33, 10, color.NRGBA{R: 0x00, A: 0xff}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := color.NRGBAModel.Convert(img.At(tc.x, tc.y)).(color.NRGBA)
+			if !colorClose(actual, tc.expected) {
+				t.Errorf("Pixel (%d, %d): expected %v, got %v", tc.x, tc.y, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestImageInheritsRootStyle(t *testing.T) {
+	svg := `<svg width="20px" height="20px" xmlns="http://www.w3.org/2000/svg" fill="red" opacity="0.5">
+		<rect x="0" y="0" width="20" height="20" />
+	</svg>`
+
+	img, err := Image(strings.NewReader(svg))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := color.NRGBA{R: 0xff, A: 0x80}
+	if actual := color.NRGBAModel.Convert(img.At(10, 10)).(color.NRGBA); actual != expected {
+		t.Errorf("Expected the fill and opacity of the root, %v, got %v", expected, actual)
+	}
+}
+
+func TestImageText(t *testing.T) {
+	svg := `<svg width="60" height="30" xmlns="http://www.w3.org/2000/svg">
+		<text x="30" y="15" font-size="20" text-anchor="middle" dominant-baseline="central" fill="black">88</text>
+	</svg>`
+
+	img, err := Image(strings.NewReader(svg))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The glyphs should be centered, leaving the edges of the image empty
+	painted := 0
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 60; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a > 0 {
+				painted++
+				if x < 10 || x > 50 {
+					t.Fatalf("Expected centered text, found ink at (%d, %d)", x, y)
+				}
+			}
+		}
+	}
+	if painted == 0 {
+		t.Errorf("Expected text to be drawn")
+	}
+}
+
+func TestImageErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		svg  string
+	}{
+		{"Not svg", `<html></html>`},
+		{"Malformed", `<svg width="10" height="10">`},
+		{"Empty size", `<svg width="0" height="10"></svg>`},
+		{"Too large", `<svg width="5000" height="10"></svg>`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Image(strings.NewReader(tc.svg)); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+
+	_, err := Image(strings.NewReader(`<svg width="5000" height="10"></svg>`))
+	if !errors.Is(err, ErrImageSize) {
+		t.Errorf("Expected ErrImageSize, got %v", err)
+	}
+}
+
+func TestImageTooComplex(t *testing.T) {
+	rect := `<rect width="4096" height="4096" fill="red"/>`
+	svg := `<svg width="4096" height="4096">` + strings.Repeat(rect, 17) + `</svg>`
+	if _, err := Image(strings.NewReader(svg)); !errors.Is(err, ErrTooComplex) {
+		t.Errorf("Expected ErrTooComplex, got %v", err)
+	}
+
+	dashed := `<svg width="100" height="100"><line x1="0" y1="50" x2="100" y2="50" stroke="black" stroke-dasharray="0 1e-9"/></svg>`
+	if _, err := Image(strings.NewReader(dashed)); !errors.Is(err, ErrTooComplex) {
+		t.Errorf("Expected ErrTooComplex for a tiny dash pattern, got %v", err)
+	}
+
+	// Small shapes only cost their own bounding box
+	small := `<rect x="10" y="10" width="4" height="4" fill="red"/>`
+	svg = `<svg width="4096" height="4096">` + strings.Repeat(small, 1000) + `</svg>`
+	if _, err := Image(strings.NewReader(svg)); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := PNG(&buf, strings.NewReader(`<svg width="8" height="4"><rect width="8" height="4" fill="blue"/></svg>`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Expected a valid PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 8 || b.Dy() != 4 {
+		t.Errorf("Expected an 8x4 image, got %v", b)
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected color.NRGBA
+		ok       bool
+	}{
+		{"#44CC11", color.NRGBA{R: 0x44, G: 0xCC, B: 0x11, A: 0xff}, true},
+		{"#4c1", color.NRGBA{R: 0x44, G: 0xCC, B: 0x11, A: 0xff}, true},
+		{"white", color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, true},
+		{"Orange", color.NRGBA{R: 0xff, G: 0xa5, A: 0xff}, true},
+		{"rgb(255, 0, 0)", color.NRGBA{R: 0xff, A: 0xff}, true},
+		{"rgba(0,0,255,0.5)", color.NRGBA{B: 0xff, A: 0x80}, true},
+		{"rgb(100%, 0%, 0%)", color.NRGBA{R: 0xff, A: 0xff}, true},
+		{"none", color.NRGBA{}, false},
+		{"#12345", color.NRGBA{}, false},
+		{"notacolor", color.NRGBA{}, false},
+	}
+	for _, tc := range testCases {
+		actual, ok := parseColor(tc.input)
+		if ok != tc.ok || actual != tc.expected {
+			t.Errorf("parseColor(%q) = %v, %v; expected %v, %v", tc.input, actual, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+func TestIsColor(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected bool
+	}{
+		{"#44CC11", true},
+		{"#4c1", true},
+		{"rebeccapurple", true},
+		{"rgb(255, 0, 0)", true},
+		{"rgba(0,0,255,0.5)", true},
+		{"none", false},
+		{"#12345", false},
+		{"notacolor", false},
+		{"rgb(1,2,3)\"><script>", false},
+		{" red", false},
+	}
+	for _, tc := range testCases {
+		if actual := IsColor(tc.input); actual != tc.expected {
+			t.Errorf("IsColor(%q) = %v; expected %v", tc.input, actual, tc.expected)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	subpaths, closed := parsePath("M50,50 L25,50 A25,25 0 1,1 75,50 Z")
+	if !closed {
+		t.Errorf("Expected the path to be closed")
+	}
+	if len(subpaths) != 1 {
+		t.Fatalf("Expected 1 subpath, got %d", len(subpaths))
+	}
+	sp := subpaths[0]
+	if sp[0] != (point{50, 50}) || sp[1] != (point{25, 50}) || sp[len(sp)-1] != (point{75, 50}) {
+		t.Errorf("Unexpected path points %v", sp)
+	}
+	// The arc sweeps over the top of the center
+	for _, p := range sp[2:] {
+		if p.y > 50+1e-9 {
+			t.Errorf("Expected the arc to stay above y=50, got %v", p)
+		}
+	}
+
+	subpaths, _ = parsePath("m10 10 h5 v5 h-5 z M0 0 l1 1")
+	if len(subpaths) != 2 {
+		t.Fatalf("Expected 2 subpaths, got %d", len(subpaths))
+	}
+	expected := []point{{10, 10}, {15, 10}, {15, 15}, {10, 15}}
+	for i, p := range expected {
+		if subpaths[0][i] != p {
+			t.Errorf("Relative path point %d: expected %v, got %v", i, p, subpaths[0][i])
+		}
+	}
+}
+
+func TestDash(t *testing.T) {
+	line := []point{{0, 0}, {10, 0}}
+
+	dashes, err := dash(line, []float64{3, 2}, 0, MaxDashes)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := [][2]float64{{0, 3}, {5, 8}}
+	if len(dashes) != len(expected) {
+		t.Fatalf("Expected %d dashes, got %d: %v", len(expected), len(dashes), dashes)
+	}
+	for i, d := range dashes {
+		if math.Abs(d[0].x-expected[i][0]) > 1e-9 || math.Abs(d[len(d)-1].x-expected[i][1]) > 1e-9 {
+			t.Errorf("Dash %d: expected %v, got %v", i, expected[i], d)
+		}
+	}
+
+	if dashes, _ := dash(line, []float64{0, 10}, 0, MaxDashes); len(dashes) != 0 {
+		t.Errorf("Expected no visible dashes, got %v", dashes)
+	}
+	if dashes, _ := dash(line, []float64{10, 0}, 0, MaxDashes); len(dashes) != 1 {
+		t.Errorf("Expected one solid dash, got %v", dashes)
+	}
+	if _, err := dash(line, []float64{0, 1e-9}, 0, MaxDashes); !errors.Is(err, ErrTooComplex) {
+		t.Errorf("Expected ErrTooComplex for a tiny pattern, got %v", err)
+	}
+	if _, err := dash(line, []float64{1, 1}, 0, 3); !errors.Is(err, ErrTooComplex) {
+		t.Errorf("Expected ErrTooComplex above the limit, got %v", err)
+	}
+}
+
+func TestParseTransform(t *testing.T) {
+	m := parseTransform("rotate(-90, 50, 50)")
+	p := m.apply(point{100, 50})
+	if math.Abs(p.x-50) > 1e-9 || math.Abs(p.y-0) > 1e-9 {
+		t.Errorf("Expected (100, 50) to rotate to (50, 0), got %v", p)
+	}
+
+	m = parseTransform("translate(10 5) scale(2)")
+	if p := m.apply(point{1, 1}); p != (point{12, 7}) {
+		t.Errorf("Expected (12, 7), got %v", p)
+	}
+}
+
+func colorClose(a, b color.NRGBA) bool {
+	near := func(x, y uint8) bool { return math.Abs(float64(x)-float64(y)) <= 2 }
+	if a.A == 0 && b.A == 0 {
+		return true
+	}
+	return near(a.R, b.R) && near(a.G, b.G) && near(a.B, b.B) && near(a.A, b.A)
+}
@@ -0,0 +1,123 @@
+package rasterize
+
+import (
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"math"
+	"strings"
+)
+
+// The Go fonts stand in for every font-family, since they are embedded in the binary.
+var (
+	regularFont = mustParseFont(goregular.TTF)
+	boldFont    = mustParseFont(gobold.TTF)
+)
+
+func mustParseFont(ttf []byte) *opentype.Font {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+type faceKey struct {
+	bold bool
+	size float64
+}
+
+// fontCache keeps the faces created while rasterizing one document.
+type fontCache struct {
+	faces map[faceKey]font.Face
+}
+
+func newFontCache() *fontCache {
+	return &fontCache{faces: map[faceKey]font.Face{}}
+}
+
+func (fc *fontCache) face(bold bool, size float64) (font.Face, error) {
+	key := faceKey{bold, size}
+	if face, ok := fc.faces[key]; ok {
+		return face, nil
+	}
+	f := regularFont
+	if bold {
+		f = boldFont
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return nil, err
+	}
+	fc.faces[key] = face
+	return face, nil
+}
+
+// drawText draws the text content of a text element, including nested tspan elements,
+// as a single line. Per-glyph positioning and rotation are not supported.
+func (c *canvas) drawText(el *element, m matrix, s style) {
+	content := strings.Join(strings.Fields(textContent(el)), " ")
+	fill, ok := parseColor(s.fill)
+	if content == "" || !ok {
+		return
+	}
+
+	size := s.fontSize * m.scale()
+	if size <= 0 {
+		return
+	}
+	bold := s.fontWeight == "bold" || s.fontWeight == "bolder" || parseNumber(s.fontWeight) >= 600
+	face, err := c.fonts.face(bold, size)
+	if err != nil {
+		return
+	}
+
+	origin := m.apply(point{parseLength(firstNumber(el.attrs["x"])), parseLength(firstNumber(el.attrs["y"]))})
+	advance := float64(font.MeasureString(face, content)) / 64
+	switch s.textAnchor {
+	case "middle":
+		origin.x -= advance / 2
+	case "end":
+		origin.x -= advance
+	}
+	metrics := face.Metrics()
+	switch s.baseline {
+	case "central", "middle":
+		origin.y += float64(metrics.CapHeight) / 64 / 2
+	case "hanging", "text-before-edge":
+		origin.y += float64(metrics.Ascent) / 64
+	case "text-after-edge", "ideographic":
+		origin.y -= float64(metrics.Descent) / 64
+	}
+
+	drawer := font.Drawer{
+		Dst:  c.dst,
+		Src:  image.NewUniform(withOpacity(fill, s.opacity*s.fillOpacity)),
+		Face: face,
+		Dot:  fixed.Point26_6{X: fixed.Int26_6(math.Round(origin.x * 64)), Y: fixed.Int26_6(math.Round(origin.y * 64))},
+	}
+	drawer.DrawString(content)
+}
+
+// textContent concatenates the character data of an element and its descendants.
+func textContent(el *element) string {
+	var sb strings.Builder
+	sb.WriteString(el.text)
+	for _, child := range el.children {
+		if child.name == "tspan" {
+			sb.WriteString(" " + textContent(child))
+		}
+	}
+	return sb.String()
+}
+
+// firstNumber returns the first entry of a coordinate list such as "10 20 30".
+func firstNumber(s string) string {
+	if fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
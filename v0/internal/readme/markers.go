@@ -0,0 +1,298 @@
+package readme
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/svggen"
+	"html"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Markers look like
+//
+//	<!-- dre:bar percentage=72 -->...<!-- /dre -->
+//
+// The chart name follows "dre:" and the remaining key=value pairs are passed to the
+// chart as query parameters. Values containing spaces can be double-quoted.
+var (
+	openMarkerRegex  = regexp.MustCompile(`<!--\s*dre:([A-Za-z][\w-]*)((?:[^-]|-[^-])*?)\s*-->`)
+	closeMarkerRegex = regexp.MustCompile(`<!--\s*/dre\s*-->`)
+	markerParamRegex = regexp.MustCompile(`([A-Za-z][\w.-]*)=("[^"]*"|[^\s"]+)`)
+)
+
+// Keys in a marker that configure the output rather than the chart.
+const (
+	fileKey = "file" // Write the chart to this path, relative to the Markdown file, instead of a data URI
+	altKey  = "alt"  // Alternative text of the generated <img>
+)
+
+// Asset is a chart file referenced by a marker with a file= key.
+// Files ending in .png are rasterized, anything else is written as SVG.
+type Asset struct {
+	Path    string // Path relative to the Markdown file
+	Content []byte
+}
+
+// Result is the outcome of updating a Markdown document.
+type Result struct {
+	Content []byte  // The document with every marker block re-rendered
+	Assets  []Asset // Chart files that the document now references
+	Markers int     // Number of marker blocks found
+}
+
+// Update renders every marker block in content and replaces the text between the
+// opening and closing markers with an <img> of the chart.
+// Markers inside fenced or indented code blocks are documentation examples and are left alone.
+// Running Update on its own output produces the same output, so callers can compare
+// Result.Content with the input to see whether anything changed.
+// Returns an error if a marker is malformed, not closed, or its chart fails to render.
+func Update(content []byte) (Result, error) {
+	var result Result
+	var out bytes.Buffer
+	code := codeBlocks(content)
+
+	pos := 0
+	for {
+		open := findMarker(openMarkerRegex, content, pos, len(content), code)
+		if open == nil {
+			out.Write(content[pos:])
+			break
+		}
+		line := lineNumber(content, open[0])
+
+		closing := findMarker(closeMarkerRegex, content, open[1], len(content), code)
+		if closing == nil {
+			return Result{}, fmt.Errorf("line %d: marker is not closed with <!-- /dre -->", line)
+		}
+		if nested := findMarker(openMarkerRegex, content, open[1], closing[0], code); nested != nil {
+			return Result{}, fmt.Errorf("line %d: marker is not closed before the next marker", line)
+		}
+
+		chart := string(content[open[2]:open[3]])
+		params, err := parseMarkerParams(string(content[open[4]:open[5]]))
+		if err != nil {
+			return Result{}, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		img, asset, err := renderMarker(chart, params)
+		if err != nil {
+			return Result{}, fmt.Errorf("line %d: %w", line, err)
+		}
+		if asset != nil {
+			result.Assets = append(result.Assets, *asset)
+		}
+		result.Markers++
+
+		// Keep both markers untouched and replace everything between them
+		out.Write(content[pos:open[1]])
+		out.WriteString("\n" + img + "\n")
+		pos = closing[0]
+	}
+
+	result.Content = out.Bytes()
+	return result, nil
+}
+
+// findMarker returns the submatch indexes, relative to the start of content, of the
+// first match of re in content[from:to] that does not start inside a code block.
+// Returns nil if there is no such match.
+func findMarker(re *regexp.Regexp, content []byte, from, to int, code [][2]int) []int {
+	for from < to {
+		match := re.FindSubmatchIndex(content[from:to])
+		if match == nil {
+			return nil
+		}
+		for i := range match {
+			if match[i] >= 0 {
+				match[i] += from
+			}
+		}
+		if !inCodeBlock(code, match[0]) {
+			return match
+		}
+		from = match[1]
+	}
+	return nil
+}
+
+// inCodeBlock reports whether offset falls inside one of the code block ranges.
+func inCodeBlock(code [][2]int, offset int) bool {
+	for _, block := range code {
+		if offset >= block[0] && offset < block[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// codeBlocks returns the byte ranges of the fenced (``` or ~~~) and indented code
+// blocks in a Markdown document. An unclosed fence runs to the end of the document,
+// and an indented block must follow a blank line since it cannot interrupt a paragraph.
+func codeBlocks(content []byte) [][2]int {
+	var blocks [][2]int
+	var fence []byte // Opening fence of the current fenced block
+	fenceStart := 0
+	indented, indentStart, indentEnd := false, 0, 0
+	prevBlank := true
+
+	for offset := 0; offset < len(content); {
+		next := len(content)
+		if i := bytes.IndexByte(content[offset:], '\n'); i >= 0 {
+			next = offset + i + 1
+		}
+		line := bytes.TrimRight(content[offset:next], "\r\n")
+		blank := len(bytes.TrimSpace(line)) == 0
+
+		if indented && !blank && indentation(line) < 4 {
+			blocks = append(blocks, [2]int{indentStart, indentEnd})
+			indented = false
+		}
+		switch {
+		case fence != nil:
+			if closesFence(line, fence) {
+				blocks = append(blocks, [2]int{fenceStart, next})
+				fence = nil
+				blank = true // Like a blank line, a closed fence ends the previous paragraph
+			}
+		case openingFence(line) != nil:
+			fence, fenceStart = openingFence(line), offset
+		case !blank && indentation(line) >= 4 && (indented || prevBlank):
+			if !indented {
+				indented, indentStart = true, offset
+			}
+			indentEnd = next
+		}
+		prevBlank = blank
+		offset = next
+	}
+
+	if fence != nil {
+		blocks = append(blocks, [2]int{fenceStart, len(content)})
+	}
+	if indented {
+		blocks = append(blocks, [2]int{indentStart, indentEnd})
+	}
+	return blocks
+}
+
+// openingFence returns the run of backticks or tildes that opens a fenced code block
+// on line, or nil if the line does not open one.
+func openingFence(line []byte) []byte {
+	if indentation(line) > 3 {
+		return nil
+	}
+	line = bytes.TrimLeft(line, " ")
+	fence := fenceRun(line)
+	if fence == nil || (fence[0] == '`' && bytes.IndexByte(line[len(fence):], '`') >= 0) {
+		return nil
+	}
+	return fence
+}
+
+// closesFence reports whether line closes the fenced code block opened by fence.
+func closesFence(line, fence []byte) bool {
+	if indentation(line) > 3 {
+		return false
+	}
+	line = bytes.TrimLeft(line, " ")
+	run := fenceRun(line)
+	return run != nil && run[0] == fence[0] && len(run) >= len(fence) &&
+		len(bytes.TrimSpace(line[len(run):])) == 0
+}
+
+// fenceRun returns the leading run of three or more backticks or tildes in line, or nil.
+func fenceRun(line []byte) []byte {
+	if len(line) == 0 || (line[0] != '`' && line[0] != '~') {
+		return nil
+	}
+	n := 0
+	for n < len(line) && line[n] == line[0] {
+		n++
+	}
+	if n < 3 {
+		return nil
+	}
+	return line[:n]
+}
+
+// indentation returns the width of the leading whitespace of line, with tabs
+// advancing to the next multiple of four.
+func indentation(line []byte) int {
+	width := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// parseMarkerParams parses the key=value pairs of an opening marker.
+func parseMarkerParams(s string) (url.Values, error) {
+	params := url.Values{}
+	last := 0
+	for _, match := range markerParamRegex.FindAllStringSubmatchIndex(s, -1) {
+		if strings.TrimSpace(s[last:match[0]]) != "" {
+			return nil, fmt.Errorf("invalid marker parameter %q", strings.TrimSpace(s[last:match[0]]))
+		}
+		value := strings.Trim(s[match[4]:match[5]], `"`)
+		params.Add(s[match[2]:match[3]], value)
+		last = match[1]
+	}
+	if strings.TrimSpace(s[last:]) != "" {
+		return nil, fmt.Errorf("invalid marker parameter %q", strings.TrimSpace(s[last:]))
+	}
+	return params, nil
+}
+
+// renderMarker renders a chart and returns the <img> element to insert,
+// plus the asset to write when the marker asks for a separate file.
+// Returns an error if the file is absolute or leaves the Markdown file's directory,
+// so a marker cannot write anywhere else.
+func renderMarker(chart string, params url.Values) (string, *Asset, error) {
+	file := params.Get(fileKey)
+	if params.Has(fileKey) && !filepath.IsLocal(filepath.FromSlash(file)) {
+		return "", nil, fmt.Errorf("file %q must be a relative path inside the Markdown file's directory", file)
+	}
+	alt := params.Get(altKey)
+	if alt == "" {
+		alt = chart
+	}
+	params.Del(fileKey)
+	params.Del(altKey)
+
+	var svg bytes.Buffer
+	if err := svggen.RenderChart(&svg, chart, params); err != nil {
+		return "", nil, err
+	}
+
+	if file != "" {
+		format := svggen.FormatSVG
+		if strings.EqualFold(path.Ext(file), ".png") {
+			format = svggen.FormatPNG
+		}
+		var content bytes.Buffer
+		if err := svggen.Encode(&content, svg.Bytes(), format); err != nil {
+			return "", nil, err
+		}
+		img := fmt.Sprintf(`<img src="%s" alt="%s">`, html.EscapeString(file), html.EscapeString(alt))
+		return img, &Asset{Path: file, Content: content.Bytes()}, nil
+	}
+	src := "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(svg.Bytes())
+	return fmt.Sprintf(`<img src="%s" alt="%s">`, src, html.EscapeString(alt)), nil, nil
+}
+
+// lineNumber returns the 1 based line of the byte at offset.
+func lineNumber(content []byte, offset int) int {
+	return bytes.Count(content[:offset], []byte("\n")) + 1
+}
@@ -0,0 +1,194 @@
+package readme
+
+import (
+	"bytes"
+	"encoding/base64"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	input := "# Status\n\n<!-- dre:bar percentage=72 alt=\"Sprint progress\" -->\nold content\n<!-- /dre -->\n\nText after.\n"
+
+	result, err := Update([]byte(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Markers != 1 {
+		t.Errorf("Expected 1 marker, got %d", result.Markers)
+	}
+
+	content := string(result.Content)
+	if strings.Contains(content, "old content") {
+		t.Errorf("Expected content between markers to be replaced, got %s", content)
+	}
+	if !strings.HasPrefix(content, "# Status\n\n<!-- dre:bar percentage=72 alt=\"Sprint progress\" -->\n<img src=\"data:image/svg+xml;base64,") {
+		t.Errorf("Expected opening marker to be kept, got %s", content)
+	}
+	if !strings.HasSuffix(content, "alt=\"Sprint progress\">\n<!-- /dre -->\n\nText after.\n") {
+		t.Errorf("Expected closing marker and trailing text to be kept, got %s", content)
+	}
+
+	encoded := regexp.MustCompile(`base64,([^"]+)"`).FindStringSubmatch(content)
+	if encoded == nil {
+		t.Fatalf("Expected a data URI in %s", content)
+	}
+	svg, err := base64.StdEncoding.DecodeString(encoded[1])
+	if err != nil {
+		t.Fatalf("Expected valid base64: %v", err)
+	}
+	if !strings.Contains(string(svg), "72%") {
+		t.Errorf("Expected the rendered bar in the data URI, got %s", svg)
+	}
+
+	again, err := Update(result.Content)
+	if err != nil {
+		t.Fatalf("Unexpected error on second update: %v", err)
+	}
+	if !bytes.Equal(again.Content, result.Content) {
+		t.Errorf("Expected update to be idempotent")
+	}
+}
+
+func TestUpdateFileAsset(t *testing.T) {
+	input := "<!-- dre:circle size=103 percentage=58 file=img/circle.svg --><!-- /dre -->"
+
+	result, err := Update([]byte(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "<!-- dre:circle size=103 percentage=58 file=img/circle.svg -->\n<img src=\"img/circle.svg\" alt=\"circle\">\n<!-- /dre -->"
+	if string(result.Content) != expected {
+		t.Errorf("Expected %q, got %q", expected, result.Content)
+	}
+	if len(result.Assets) != 1 || result.Assets[0].Path != "img/circle.svg" {
+		t.Fatalf("Expected one asset for img/circle.svg, got %+v", result.Assets)
+	}
+	if !strings.Contains(string(result.Assets[0].Content), `stroke-dasharray="132.9476, 96.2724"`) {
+		t.Errorf("Expected the rendered circle in the asset, got %s", result.Assets[0].Content)
+	}
+}
+
+func TestUpdateSkipsCodeBlocks(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+	}{
+		{"Backtick fence", "Example:\n\n```markdown\n<!-- dre:bar percentage=72 alt=\"Sprint progress\" -->\n<!-- /dre -->\n```\n"},
+		{"Tilde fence", "~~~~\n<!-- dre:bar percentage=72 -->\n~~~\n<!-- /dre -->\n~~~~\n"},
+		{"Indented fence", "  ```\n<!-- dre:bar -->old<!-- /dre -->\n  ```\n"},
+		{"Unclosed fence", "```\n<!-- dre:bar --><!-- /dre -->\n"},
+		{"Indented code", "Example:\n\n    <!-- dre:bar percentage=72 -->\n\n    <!-- /dre -->\n"},
+		{"Tab indented code", "\t<!-- dre:bar --><!-- /dre -->\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Update([]byte(tc.input))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Markers != 0 {
+				t.Errorf("Expected no markers, got %d", result.Markers)
+			}
+			if string(result.Content) != tc.input {
+				t.Errorf("Expected content to be unchanged, got %q", result.Content)
+			}
+		})
+	}
+
+	// Markers around a fenced example are still rendered
+	input := "```markdown\n<!-- dre:bar percentage=72 -->\n<!-- /dre -->\n```\n\n<!-- dre:bar percentage=10 -->\nold\n<!-- /dre -->\nparagraph\n    <!-- dre:bar percentage=20 -->old<!-- /dre -->\n"
+	result, err := Update([]byte(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Markers != 2 {
+		t.Errorf("Expected 2 markers, got %d", result.Markers)
+	}
+	content := string(result.Content)
+	if !strings.HasPrefix(content, "```markdown\n<!-- dre:bar percentage=72 -->\n<!-- /dre -->\n```\n\n<!-- dre:bar percentage=10 -->\n<img ") {
+		t.Errorf("Expected the fenced example to be kept, got %s", content)
+	}
+	if strings.Contains(content, "old") {
+		t.Errorf("Expected markers outside code to be rendered, got %s", content)
+	}
+}
+
+func TestUpdateErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         string
+		expectedError string
+	}{
+		{
+			name:          "Unclosed marker",
+			input:         "line one\n<!-- dre:bar percentage=72 -->\n",
+			expectedError: "line 2: marker is not closed",
+		},
+		{
+			name:          "Nested marker",
+			input:         "<!-- dre:bar --><!-- dre:circle --><!-- /dre -->",
+			expectedError: "line 1: marker is not closed before the next marker",
+		},
+		{
+			name:          "Unknown chart",
+			input:         "<!-- dre:pie --><!-- /dre -->",
+			expectedError: `line 1: unknown chart "pie"`,
+		},
+		{
+			name:          "Invalid chart parameter",
+			input:         "<!-- dre:calendar month=13 --><!-- /dre -->",
+			expectedError: "line 1: Month must be between 1 and 12",
+		},
+		{
+			name:          "Malformed parameter",
+			input:         "<!-- dre:bar percentage --><!-- /dre -->",
+			expectedError: `line 1: invalid marker parameter "percentage"`,
+		},
+		{
+			name:          "File outside the directory",
+			input:         "text\n<!-- dre:bar file=../../../etc/x.svg --><!-- /dre -->",
+			expectedError: `line 2: file "../../../etc/x.svg" must be a relative path inside the Markdown file's directory`,
+		},
+		{
+			name:          "Absolute file",
+			input:         "<!-- dre:bar file=/etc/x.svg --><!-- /dre -->",
+			expectedError: `line 1: file "/etc/x.svg" must be a relative path`,
+		},
+		{
+			name:          "Empty file",
+			input:         `<!-- dre:bar file="" --><!-- /dre -->`,
+			expectedError: `line 1: file "" must be a relative path`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Update([]byte(tc.input))
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("Expected error containing %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestParseMarkerParams(t *testing.T) {
+	params, err := parseMarkerParams(` year=2024 month=3  progressDays=1,2,3 alt="March habits" `)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string][]string{
+		"year":         {"2024"},
+		"month":        {"3"},
+		"progressDays": {"1,2,3"},
+		"alt":          {"March habits"},
+	}
+	if !reflect.DeepEqual(map[string][]string(params), expected) {
+		t.Errorf("Expected %v, got %v", expected, params)
+	}
+}
@@ -0,0 +1,65 @@
+// Package server runs the HTTP server with timeouts and a graceful shutdown.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/config"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// New returns a server for handler listening on addr, with the timeouts and
+// header limit of cfg.
+func New(addr string, handler http.Handler, cfg config.Server) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: time.Duration(cfg.ReadHeaderTimeout),
+		ReadTimeout:       time.Duration(cfg.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.IdleTimeout),
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+// Run serves srv until ctx is done, then stops accepting connections and waits up to
+// drain for the requests in flight to finish. Returns an error if the address cannot
+// be bound, the server fails, or requests were still running when drain ran out.
+func Run(ctx context.Context, srv *http.Server, drain time.Duration) error {
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %w", srv.Addr, err)
+	}
+	return Serve(ctx, srv, listener, drain)
+}
+
+// Serve is Run on a listener that is already bound. The listener is closed on return.
+func Serve(ctx context.Context, srv *http.Server, listener net.Listener, drain time.Duration) error {
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(listener)
+	}()
+	slog.Info("Server listening", "addr", listener.Addr().String())
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, draining requests in flight", "timeout", drain)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drain)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("requests still running after %s: %w", drain, err)
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
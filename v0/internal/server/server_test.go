@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/config"
+)
+
+// slowHandler answers after delay, closing started when the request arrives.
+func slowHandler(delay time.Duration, started chan<- struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(delay)
+		io.WriteString(w, "done")
+	})
+}
+
+func TestServeDrainsRequestsInFlight(t *testing.T) {
+	testCases := []struct {
+		name        string
+		delay       time.Duration
+		drain       time.Duration
+		expectError bool
+	}{
+		{"Request finishes within the drain period", 200 * time.Millisecond, 5 * time.Second, false},
+		{"Request outlasts the drain period", 2 * time.Second, 100 * time.Millisecond, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			started := make(chan struct{})
+			srv := New(listener.Addr().String(), slowHandler(tc.delay, started), config.Default().Server)
+			ctx, cancel := context.WithCancel(context.Background())
+			stopped := make(chan error, 1)
+			go func() {
+				stopped <- Serve(ctx, srv, listener, tc.drain)
+			}()
+
+			responses := make(chan string, 1)
+			go func() {
+				resp, err := http.Get("http://" + listener.Addr().String())
+				if err != nil {
+					responses <- err.Error()
+					return
+				}
+				defer resp.Body.Close()
+				body, _ := io.ReadAll(resp.Body)
+				responses <- string(body)
+			}()
+			<-started
+			cancel()
+
+			err = <-stopped
+			if (err != nil) != tc.expectError {
+				t.Errorf("Expected error %v, got %v", tc.expectError, err)
+			}
+			if response := <-responses; !tc.expectError && response != "done" {
+				t.Errorf("Expected the request in flight to finish, got %q", response)
+			}
+		})
+	}
+}
+
+func TestRunBindError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	srv := New(listener.Addr().String(), http.NotFoundHandler(), config.Default().Server)
+	err = Run(context.Background(), srv, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "cannot listen on") {
+		t.Errorf("Expected a listen error, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	cfg := config.Default().Server
+	srv := New(":8080", http.NotFoundHandler(), cfg)
+	if srv.ReadHeaderTimeout != time.Duration(cfg.ReadHeaderTimeout) || srv.WriteTimeout != time.Duration(cfg.WriteTimeout) ||
+		srv.IdleTimeout != time.Duration(cfg.IdleTimeout) || srv.MaxHeaderBytes != cfg.MaxHeaderBytes {
+		t.Errorf("Expected the server to use the configured limits, got %+v", srv)
+	}
+}
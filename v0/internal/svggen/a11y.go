@@ -0,0 +1,33 @@
+package svggen
+
+import (
+	"strings"
+)
+
+// accessibility is the text a screen reader announces for a chart. Title is used for the
+// aria-label and <title> of the SVG, and Desc, when not empty, for its <desc>.
+type accessibility struct {
+	Title, Desc string
+}
+
+// describe returns the accessible text of a chart. The title and desc given in the options
+// are used as they are; each one left empty is replaced by the text generated from the chart.
+func describe(title, desc, defaultTitle, defaultDesc string) accessibility {
+	if title == "" {
+		title = defaultTitle
+	}
+	if desc == "" {
+		desc = defaultDesc
+	}
+	return accessibility{Title: title, Desc: desc}
+}
+
+// readAccessibility reads the title and desc parameters, which replace the generated text.
+func readAccessibility(r *paramReader) (title, desc string) {
+	return r.String("title", ""), r.String("desc", "")
+}
+
+// percentText spells out a percentage for a screen reader, such as "72 percent".
+func percentText(percentage float64, decimals int) string {
+	return strings.TrimSuffix(formatPercentage(percentage, decimals), "%") + " percent"
+}
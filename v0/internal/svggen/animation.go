@@ -0,0 +1,92 @@
+package svggen
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultEasing is used when an animation does not name one.
+const DefaultEasing = "ease-out"
+
+// Limits of the animation duration accepted from a request, in seconds.
+const (
+	defaultAnimationSeconds = 1.0
+	maxAnimationSeconds     = 60
+)
+
+// easings map each easing name to the control points of its cubic Bézier curve,
+// matching the CSS timing functions of the same name.
+var easings = map[string]string{
+	"linear":      "0 0 1 1",
+	"ease":        "0.25 0.1 0.25 1",
+	"ease-in":     "0.42 0 1 1",
+	"ease-out":    "0 0 0.58 1",
+	"ease-in-out": "0.42 0 0.58 1",
+}
+
+// Animation makes a progress chart fill in from zero when it is shown. The animation
+// uses SMIL only, no scripts, so it survives image proxies such as GitHub's camo.
+// The static attributes keep the final values, so renderers that do not animate,
+// including the PNG rasterizer, show the finished chart. The zero value is no animation.
+type Animation struct {
+	Duration time.Duration // Length of the animation, no animation when zero
+	Easing   string        // One of Easings, DefaultEasing when empty
+}
+
+// Easings returns the supported easing names in alphabetical order.
+func Easings() []string {
+	names := make([]string, 0, len(easings))
+	for name := range easings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// smilAnimation holds the attributes shared by the <animate> elements of a chart.
+type smilAnimation struct {
+	Dur        string // Clock value such as "1.5s"
+	KeySplines string
+}
+
+// smil returns the SMIL attributes of the animation, or nil when there is no animation.
+// Returns a *ParamError if the duration is negative or the easing is unknown.
+func (a Animation) smil() (*smilAnimation, error) {
+	if a.Duration < 0 {
+		return nil, &ParamError{"Animation duration must not be negative"}
+	}
+	if a.Duration == 0 {
+		return nil, nil
+	}
+	easing := a.Easing
+	if easing == "" {
+		easing = DefaultEasing
+	}
+	keySplines, ok := easings[easing]
+	if !ok {
+		return nil, &ParamError{fmt.Sprintf("Invalid easing: %s (must be %s)", easing, joinOptions(Easings()))}
+	}
+	return &smilAnimation{
+		Dur:        strconv.FormatFloat(a.Duration.Seconds(), 'f', -1, 64) + "s",
+		KeySplines: keySplines,
+	}, nil
+}
+
+// readAnimation reads the animate, duration and easing parameters.
+// The duration, in seconds, and the easing are only read when animate is true.
+func readAnimation(r *paramReader) Animation {
+	if !r.Bool("animate", false) {
+		return Animation{}
+	}
+	seconds := r.Float("duration", defaultAnimationSeconds)
+	if seconds <= 0 || seconds > maxAnimationSeconds {
+		r.Fail("duration", fmt.Sprintf("Duration must be more than 0 and at most %d seconds", maxAnimationSeconds))
+		seconds = defaultAnimationSeconds
+	}
+	return Animation{
+		Duration: time.Duration(seconds * float64(time.Second)),
+		Easing:   r.Enum("easing", DefaultEasing, Easings()...),
+	}
+}
@@ -0,0 +1,306 @@
+package svggen
+
+import (
+	"fmt"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/rasterize"
+	"github.com/gin-gonic/gin"
+	"html/template"
+	"io"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const badgeTemplateStr = `
+<svg width="{{.Width}}px" height="{{.Height}}px" xmlns="http://www.w3.org/2000/svg" font-family="Verdana, Geneva, sans-serif" font-size="{{.FontSize}}"{{if .Bold}} font-weight="bold"{{end}} role="img" aria-label="{{.A11y.Title}}">
+	<title>{{.A11y.Title}}</title>{{with .A11y.Desc}}<desc>{{.}}</desc>{{end}}{{.Style}}
+	<rect x="0" y="0" width="{{.Width}}px" height="{{.Height}}px" rx="{{.Radius}}" fill="{{.ColorMessage}}" />
+	{{- if .LabelWidth}}
+	<rect x="0" y="0" width="{{.LabelWidth}}px" height="{{.Height}}px" rx="{{.Radius}}" fill="{{.ColorLabel}}" />
+	{{- end}}
+	{{- if .Gloss}}
+	<rect x="0" y="0" width="{{.Width}}px" height="{{.Gloss}}px" rx="{{.Radius}}" fill="#fff" opacity="0.15" />
+	{{- end}}
+	{{- with .Logo}}
+	<g transform="{{.Transform}}" fill="{{.Color}}">
+		{{- range .Paths}}
+		<path d="{{.}}" />
+		{{- end}}
+	</g>
+	{{- end}}
+	{{- if .Label}}
+	<text x="{{.LabelX}}" y="{{.TextY}}" text-anchor="middle" fill="{{.ColorLabelText}}">{{.Label}}</text>
+	{{- end}}
+	<text x="{{.MessageX}}" y="{{.TextY}}" text-anchor="middle" fill="{{.ColorMessageText}}">{{.Message}}</text>
+</svg>
+`
+
+var badgeTemplate = template.Must(template.New("badge").Parse(badgeTemplateStr))
+
+// Badge styles, named after the shields.io styles they imitate.
+const (
+	BadgeStyleFlat        = "flat"
+	BadgeStyleFlatSquare  = "flat-square"
+	BadgeStylePlastic     = "plastic"
+	BadgeStyleForTheBadge = "for-the-badge"
+)
+
+// badgeStyle holds the dimensions of a badge style.
+type badgeStyle struct {
+	Height, Radius, Padding int
+	FontSize                float64
+	Bold, Uppercase, Gloss  bool
+}
+
+var badgeStyles = map[string]badgeStyle{
+	BadgeStyleFlat:        {Height: 20, Radius: 3, Padding: 6, FontSize: 11},
+	BadgeStyleFlatSquare:  {Height: 20, Padding: 6, FontSize: 11},
+	BadgeStylePlastic:     {Height: 18, Radius: 4, Padding: 6, FontSize: 11, Gloss: true},
+	BadgeStyleForTheBadge: {Height: 28, Padding: 12, FontSize: 10, Bold: true, Uppercase: true},
+}
+
+// BadgeStyles returns the supported badge style names in alphabetical order.
+func BadgeStyles() []string {
+	names := make([]string, 0, len(badgeStyles))
+	for name := range badgeStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+const (
+	// maxBadgeRunes limits the label and message of a badge.
+	maxBadgeRunes = 120
+
+	// logoSize is the width and height of a logo on a 20 pixel badge, scaled with the height.
+	logoSize = 14
+	// logoGap separates a logo from the label.
+	logoGap = 3
+
+	// badgeDarkText is drawn on bright backgrounds where the white text of the theme would be
+	// hard to read. It is not a theme color, so dark color schemes leave it alone.
+	badgeDarkText = "#333"
+)
+
+// BadgeOptions configures a badge showing a label and a message, in the style of shields.io.
+type BadgeOptions struct {
+	Label, Message string         // Text on the left and right part, the label may be empty
+	Color          string         // Background of the message, a ColorSet name or CSS color; the theme's fill when empty
+	LabelColor     string         // Background of the label, a ColorSet name or CSS color; the theme's track when empty
+	Style          string         // One of the BadgeStyle constants, BadgeStyleFlat when empty
+	Logo           string         // Name of the logo drawn before the label, see Logos
+	LogoColor      string         // Color of the logo, that of the label text when empty
+	Theme          string         // Name of the color theme, see Themes
+	Colors         ColorOverrides // Colors replacing those of the theme
+	Title, Desc    string         // Accessible title and description, generated from the text when empty
+}
+
+// DefaultBadgeOptions returns the options used when a parameter is not provided.
+func DefaultBadgeOptions() BadgeOptions {
+	return BadgeOptions{Style: BadgeStyleFlat}
+}
+
+// HandleBadge draws a badge from the label, message, color and style query parameters.
+func HandleBadge(c *gin.Context) {
+	handleChart(c, renderBadge, nil)
+}
+
+// BadgeHandler returns a handler drawing a badge with the label and message returned by
+// text. The label can still be replaced with the label parameter, and the colors, style
+// and accessible text are read from the query like for HandleBadge.
+func BadgeHandler(text func(c *gin.Context) (label, message string)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		label, message := text(c)
+		// The text goes into the query so the ETag changes along with it
+		query := c.Request.URL.Query()
+		if !query.Has("label") {
+			query.Set("label", label)
+		}
+		query.Set("message", message)
+		c.Request.URL.RawQuery = query.Encode()
+		handleChart(c, renderBadge, nil)
+	}
+}
+
+func renderBadge(w io.Writer, params url.Values) error {
+	opts := DefaultBadgeOptions()
+	r := newParamReader(params)
+	opts.Label = r.String("label", opts.Label)
+	opts.Message = r.String("message", opts.Message)
+	opts.Color = readBadgeColor(r, "color")
+	opts.LabelColor = readBadgeColor(r, "labelColor")
+	opts.Style = r.Enum("style", opts.Style, BadgeStyles()...)
+	if r.Has("logo") {
+		opts.Logo = r.Enum("logo", "", Logos()...)
+	}
+	opts.LogoColor = readBadgeColor(r, "logoColor")
+	opts.Theme = readTheme(r, settings.Theme)
+	opts.Colors = readColorOverrides(r)
+	opts.Title, opts.Desc = readAccessibility(r)
+
+	if !r.Has("message") {
+		r.Fail("message", "Message is required")
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+	return RenderBadge(w, opts)
+}
+
+// readBadgeColor reads a color parameter that may also name a ColorSet color.
+func readBadgeColor(r *paramReader, name string) string {
+	color := r.String(name, "")
+	if _, ok := namedColor(color); color != "" && !ok {
+		r.Fail(name, fmt.Sprintf("Invalid %s: %s (must be a hex, rgb() or CSS color name)", name, color))
+		return ""
+	}
+	return color
+}
+
+// textOn returns the color of text drawn over background: text, or a dark gray
+// when the background is too bright for it.
+func textOn(background, text string) string {
+	c, ok := rasterize.ParseColor(background)
+	if !ok {
+		return text
+	}
+	// Relative luminance of the sRGB color, as defined by WCAG
+	linear := func(channel uint8) float64 {
+		v := float64(channel) / 255
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	if 0.2126*linear(c.R)+0.7152*linear(c.G)+0.0722*linear(c.B) > 0.5 {
+		return badgeDarkText
+	}
+	return text
+}
+
+// badgeLogo places a logo icon on a badge.
+type badgeLogo struct {
+	Transform, Color string
+	Paths            []string
+}
+
+// RenderBadge writes a badge SVG to w. Without colors of its own, the label is drawn over
+// the track color of the theme and the message over its fill color. Each part is as wide
+// as its text measured in Verdana, and a badge without a label or logo has a single part.
+// Returns a *ParamError if the message is empty, the label or message is too long, the
+// style, logo or theme is unknown or a color is invalid.
+func RenderBadge(w io.Writer, opts BadgeOptions) error {
+	if opts.Message == "" {
+		return &ParamError{"Message must not be empty"}
+	}
+	for _, part := range []struct{ name, text string }{{"Label", opts.Label}, {"Message", opts.Message}} {
+		if utf8.RuneCountInString(part.text) > maxBadgeRunes {
+			return &ParamError{fmt.Sprintf("%s must be at most %d characters", part.name, maxBadgeRunes)}
+		}
+	}
+	if opts.Style == "" {
+		opts.Style = BadgeStyleFlat
+	}
+	style, ok := badgeStyles[opts.Style]
+	if !ok {
+		return &ParamError{fmt.Sprintf("Unknown badge style: %s (must be one of %s)", opts.Style, strings.Join(BadgeStyles(), ", "))}
+	}
+	paths, ok := logos[opts.Logo]
+	if !ok && opts.Logo != "" {
+		return &ParamError{fmt.Sprintf("Unknown logo: %s (must be one of %s)", opts.Logo, strings.Join(Logos(), ", "))}
+	}
+	theme, err := resolveTheme(opts.Theme, opts.Colors)
+	if err != nil {
+		return err
+	}
+
+	colorLabel, colorMessage, colorLogo := theme.Track, theme.Fill, ""
+	for _, c := range []struct {
+		name, value string
+		target      *string
+	}{{"", opts.Color, &colorMessage}, {"label ", opts.LabelColor, &colorLabel}, {"logo ", opts.LogoColor, &colorLogo}} {
+		if c.value == "" {
+			continue
+		}
+		resolved, ok := namedColor(c.value)
+		if !ok {
+			return &ParamError{fmt.Sprintf("Invalid %scolor: %s (must be a hex, rgb() or CSS color name)", c.name, c.value)}
+		}
+		*c.target = resolved
+	}
+	colorLabelText := textOn(colorLabel, theme.OnFill)
+	if colorLogo == "" {
+		colorLogo = colorLabelText
+	}
+
+	label, message := opts.Label, opts.Message
+	if style.Uppercase {
+		label, message = strings.ToUpper(label), strings.ToUpper(message)
+	}
+
+	// The label part holds the logo and the label text, each when present
+	labelWidth, labelX := 0, 0
+	var logo *badgeLogo
+	if len(paths) > 0 {
+		size := logoSize * style.Height / badgeStyles[BadgeStyleFlat].Height
+		logo = &badgeLogo{
+			Transform: fmt.Sprintf("translate(%d %g) scale(%g)", style.Padding, float64(style.Height-size)/2, math.Round(float64(size)/logoViewBox*1000)/1000),
+			Color:     colorLogo,
+			Paths:     paths,
+		}
+		labelWidth = style.Padding + size
+		if label == "" {
+			labelWidth += style.Padding
+		} else {
+			labelWidth += logoGap
+		}
+	}
+	if label != "" {
+		if labelWidth == 0 {
+			labelWidth = style.Padding
+		}
+		width := int(textWidth(label, style.FontSize, style.Bold))
+		labelX = labelWidth + width/2
+		labelWidth += width + style.Padding
+	}
+	messageWidth := int(textWidth(message, style.FontSize, style.Bold)) + 2*style.Padding
+
+	gloss := 0
+	if style.Gloss {
+		gloss = style.Height / 2
+	}
+	data := struct {
+		Width, Height, Radius, LabelWidth, LabelX, MessageX, TextY, Gloss int
+		FontSize                                                          float64
+		Bold                                                              bool
+		Label, Message                                                    string
+		ColorLabel, ColorMessage, ColorLabelText, ColorMessageText        string
+		Logo                                                              *badgeLogo
+		Style                                                             template.HTML
+		A11y                                                              accessibility
+	}{
+		Width:            labelWidth + messageWidth,
+		Height:           style.Height,
+		Radius:           style.Radius,
+		LabelWidth:       labelWidth,
+		LabelX:           labelX,
+		MessageX:         labelWidth + messageWidth/2,
+		TextY:            style.Height/2 + 4,
+		Gloss:            gloss,
+		FontSize:         style.FontSize,
+		Bold:             style.Bold,
+		Label:            label,
+		Message:          message,
+		ColorLabel:       colorLabel,
+		ColorMessage:     colorMessage,
+		ColorLabelText:   colorLabelText,
+		ColorMessageText: textOn(colorMessage, theme.OnFill),
+		Logo:             logo,
+		Style:            theme.Style(),
+		A11y:             describe(opts.Title, opts.Desc, strings.TrimPrefix(opts.Label+": "+opts.Message, ": "), ""),
+	}
+	return badgeTemplate.Execute(w, data)
+}
@@ -0,0 +1,233 @@
+package svggen
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestHandleBadge tests the HandleBadge function.
+func TestHandleBadge(t *testing.T) {
+	router := gin.Default()
+	router.GET("/badge", HandleBadge)
+
+	testCases := []struct {
+		name           string
+		queryString    string
+		expectedStatus int
+		expectedInBody []string
+	}{
+		{
+			name:           "Normal case - Full Template",
+			queryString:    "/badge?label=version&message=v1.4.2",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{
+				`<svg width="101px" height="20px" xmlns="http://www.w3.org/2000/svg" font-family="Verdana, Geneva, sans-serif" font-size="11" role="img" aria-label="version: v1.4.2">`,
+				fmt.Sprintf(`<rect x="0" y="0" width="101px" height="20px" rx="3" fill="%s" />`, Colors.Green),
+				fmt.Sprintf(`<rect x="0" y="0" width="52px" height="20px" rx="3" fill="%s" />`, Colors.Grey),
+				fmt.Sprintf(`<text x="26" y="14" text-anchor="middle" fill="%s">version</text>`, Colors.White),
+				fmt.Sprintf(`<text x="76" y="14" text-anchor="middle" fill="%s">v1.4.2</text>`, Colors.White),
+			},
+		},
+		{
+			name:           "Proportional text width",
+			queryString:    "/badge?label=iiii&message=WWWW",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`width="24px" height="20px" rx="3" fill="#7A7A7A"`, `<svg width="80px"`},
+		},
+		{
+			name:           "Message only",
+			queryString:    "/badge?message=passing",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`<svg width="54px"`, `aria-label="passing"`, `<text x="27" y="14" text-anchor="middle" fill="white">passing</text>`},
+		},
+		{
+			name:           "ColorSet and CSS colors",
+			queryString:    "/badge?label=coverage&message=87%25&color=Blue&labelColor=%23222",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{fmt.Sprintf(`rx="3" fill="%s" />`, Colors.Blue), `rx="3" fill="#222" />`},
+		},
+		{
+			name:           "Dark text on a bright color",
+			queryString:    "/badge?label=status&message=flaky&color=yellow",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`fill="#333">flaky</text>`, `fill="white">status</text>`},
+		},
+		{
+			name:           "Flat square",
+			queryString:    "/badge?label=a&message=b&style=flat-square",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`height="20px" rx="0"`},
+		},
+		{
+			name:           "Plastic",
+			queryString:    "/badge?label=a&message=b&style=plastic",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`height="18px" rx="4"`, `height="9px" rx="4" fill="#fff" opacity="0.15" />`, `y="13"`},
+		},
+		{
+			name:           "For the badge",
+			queryString:    "/badge?label=coverage&message=87%25&style=for-the-badge",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`font-size="10" font-weight="bold"`, `height="28px" rx="0"`, `>COVERAGE</text>`, `aria-label="coverage: 87%"`},
+		},
+		{
+			name:           "Logo",
+			queryString:    "/badge?label=build&message=passing&logo=check&logoColor=teal",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{
+				fmt.Sprintf(`<g transform="translate(6 3) scale(0.583)" fill="%s">`, Colors.Teal),
+				fmt.Sprintf(`<path d="%s" />`, logos["check"][0]),
+				`width="56px" height="20px" rx="3" fill="#7A7A7A"`,
+			},
+		},
+		{
+			name:           "Logo without label",
+			queryString:    "/badge?message=passing&logo=check",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`width="26px" height="20px" rx="3" fill="#7A7A7A"`, `<g transform="translate(6 3) scale(0.583)" fill="white">`},
+		},
+		{
+			name:           "Missing message",
+			queryString:    "/badge?label=build",
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"Message is required"},
+		},
+		{
+			name:           "Invalid parameters",
+			queryString:    "/badge?message=ok&color=nope&style=round&logo=unicorn",
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"Invalid color: nope", "Invalid style: round", "Invalid logo: unicorn"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.queryString, nil)
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+
+			body := w.Body.String()
+			for _, str := range tc.expectedInBody {
+				if !strings.Contains(body, str) {
+					t.Errorf("Expected to find %s in response body %s", str, body)
+				}
+			}
+		})
+	}
+}
+
+// TestBadgeHandler tests the BadgeHandler function.
+func TestBadgeHandler(t *testing.T) {
+	message := "v1.4.2"
+	router := gin.Default()
+	router.GET("/badge/version", BadgeHandler(func(c *gin.Context) (string, string) {
+		return "version", message
+	}))
+
+	testCases := []struct {
+		name           string
+		queryString    string
+		expectedStatus int
+		expectedInBody []string
+	}{
+		{
+			name:           "Normal case",
+			queryString:    "/badge/version",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`aria-label="version: v1.4.2"`, `>version</text>`, `>v1.4.2</text>`},
+		},
+		{
+			name:           "Label and colors",
+			queryString:    "/badge/version?label=build&active=%23123456&theme=dark",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`>build</text>`, `fill="#123456"`, `fill="#30363D"`, `aria-label="build: v1.4.2"`},
+		},
+		{
+			name:           "Message cannot be replaced",
+			queryString:    "/badge/version?message=v9",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`>v1.4.2</text>`},
+		},
+		{
+			name:           "Unknown theme",
+			queryString:    "/badge/version?theme=neon",
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"Unknown theme: neon"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.queryString, nil)
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+
+			body := w.Body.String()
+			for _, str := range tc.expectedInBody {
+				if !strings.Contains(body, str) {
+					t.Errorf("Expected to find %s in response body", str)
+				}
+			}
+		})
+	}
+
+	// A new message is a new ETag, so clients holding the old badge get the new one
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/badge/version", nil))
+	etag := w.Header().Get("ETag")
+	message = "v1.5.0"
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/badge/version", nil)
+	req.Header.Set("If-None-Match", etag)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), ">v1.5.0</text>") {
+		t.Errorf("Expected the new version after it changed, got status %d and %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRenderBadgeErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     BadgeOptions
+		expected string
+	}{
+		{"Empty message", BadgeOptions{Label: "build"}, "Message must not be empty"},
+		{"Long message", BadgeOptions{Label: "build", Message: strings.Repeat("x", maxBadgeRunes+1)}, "Message must be at most 120 characters"},
+		{"Unknown style", BadgeOptions{Message: "ok", Style: "round"}, "Unknown badge style: round (must be one of flat, flat-square, for-the-badge, plastic)"},
+		{"Unknown logo", BadgeOptions{Message: "ok", Logo: "unicorn"}, "Unknown logo: unicorn"},
+		{"Invalid color", BadgeOptions{Message: "ok", LabelColor: "nope"}, "Invalid label color: nope (must be a hex, rgb() or CSS color name)"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := RenderBadge(&strings.Builder{}, tc.opts)
+			if err == nil || !strings.HasPrefix(err.Error(), tc.expected) {
+				t.Errorf("Expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestLogos(t *testing.T) {
+	names := Logos()
+	if len(names) == 0 {
+		t.Fatal("Expected embedded logos")
+	}
+	for _, name := range names {
+		if len(logos[name]) == 0 {
+			t.Errorf("Expected path data for logo %s", name)
+		}
+	}
+}
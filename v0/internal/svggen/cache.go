@@ -0,0 +1,131 @@
+package svggen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/buildinfo"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CachePolicy sets the Cache-Control max-age of successful chart responses.
+// A zero max-age sends no-cache, so clients revalidate every time using the ETag.
+type CachePolicy struct {
+	MaxAge      time.Duration // For charts whose parameters pin everything they draw
+	TodayMaxAge time.Duration // For charts that depend on the current date, such as the default calendar
+}
+
+// DefaultCachePolicy returns a policy that caches pinned charts for a day
+// and charts that depend on the current date for five minutes.
+func DefaultCachePolicy() CachePolicy {
+	return CachePolicy{MaxAge: 24 * time.Hour, TodayMaxAge: 5 * time.Minute}
+}
+
+var cachePolicy = DefaultCachePolicy()
+
+// SetCachePolicy replaces the cache policy of every chart handler.
+// It is meant to be called once at startup, before the server handles requests.
+func SetCachePolicy(policy CachePolicy) {
+	cachePolicy = policy
+}
+
+// cacheControl returns the Cache-Control header value for a max-age.
+func cacheControl(maxAge time.Duration) string {
+	if maxAge <= 0 {
+		return "no-cache"
+	}
+	return fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+}
+
+// usesTodayFunc reports whether a chart drawn from params depends on the current date.
+type usesTodayFunc func(params url.Values) bool
+
+// buildID identifies the running binary, so a new release that draws charts
+// differently does not answer with the ETags of the old one.
+var buildID = buildinfo.Read().Version + " " + buildinfo.Read().Revision
+
+// SetBuildID replaces the build identifier hashed into every ETag, for builds whose
+// version is given at link time rather than recorded by the toolchain.
+// It is meant to be called once at startup, before the server handles requests.
+func SetBuildID(id string) {
+	buildID = id
+}
+
+// normalizeParams encodes params with the keys sorted and only the first value of each key
+// kept, which is what the charts read, so equivalent queries encode the same. A key whose
+// first value is empty is dropped, as the charts use its default even when a later value is set.
+// The format parameter is left out because the negotiated format is hashed on its own.
+func normalizeParams(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if key != "format" && queryOrDefault(params, key, "") != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, key := range keys {
+		if b.Len() > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(url.QueryEscape(key))
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(params[key][0]))
+	}
+	return b.String()
+}
+
+// chartETag returns a strong ETag for a chart. The day is the current date for charts
+// that depend on it and empty otherwise, so those ETags change at midnight.
+// The build and the settings are hashed too, as both change what a request draws.
+func chartETag(path string, params url.Values, format, day string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{buildID, settingsID, path, normalizeParams(params), format, day}, "\n")))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// currentDay returns today's date in the timezone of the tz parameter.
+// An unknown timezone falls back to the server's, the render reports the error.
+func currentDay(params url.Values) string {
+	loc, err := loadTimezone(queryOrDefault(params, "tz", ""))
+	if err != nil {
+		loc = time.Local
+	}
+	return time.Now().In(loc).Format(time.DateOnly)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag.
+// Weak validators match too, as RFC 9110 asks for GET requests.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// setCacheHeaders sets the ETag and Cache-Control headers of a successful chart response
+// and returns the ETag. notModified is true if the request already holds this chart and
+// a 304 has been sent instead.
+func setCacheHeaders(c *gin.Context, format string, usesToday usesTodayFunc) (etag string, notModified bool) {
+	params := c.Request.URL.Query()
+	maxAge, day := cachePolicy.MaxAge, ""
+	if usesToday != nil && usesToday(params) {
+		maxAge, day = cachePolicy.TodayMaxAge, currentDay(params)
+	}
+	etag = chartETag(c.Request.URL.Path, params, format, day)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", cacheControl(maxAge))
+
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		c.Status(http.StatusNotModified)
+		return etag, true
+	}
+	return etag, false
+}
@@ -0,0 +1,136 @@
+package svggen
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleChartCaching(t *testing.T) {
+	router := gin.Default()
+	router.GET("/calendar", HandleCalendar)
+	router.GET("/calendar/year", HandleCalendarYear)
+	router.GET("/progress/bar", HandleProgressBar)
+
+	testCases := []struct {
+		name                 string
+		queryString          string
+		expectedStatus       int
+		expectedCacheControl string
+		expectETag           bool
+	}{
+		{"Pinned progress bar", "/progress/bar?percentage=50", http.StatusOK, "public, max-age=86400", true},
+		{"Default calendar", "/calendar", http.StatusOK, "public, max-age=300", true},
+		{"Calendar without days marks today", "/calendar?year=2024&month=2", http.StatusOK, "public, max-age=300", true},
+		{"Calendar outlining today", "/calendar?year=2024&month=2&progressDays=1&today=true", http.StatusOK, "public, max-age=300", true},
+		{"Pinned calendar", "/calendar?year=2024&month=2&progressDays=1,2", http.StatusOK, "public, max-age=86400", true},
+		{"Default year", "/calendar/year", http.StatusOK, "public, max-age=300", true},
+		{"Pinned year", "/calendar/year?year=2024", http.StatusOK, "public, max-age=86400", true},
+		{"Error", "/progress/bar?percentage=abc", http.StatusBadRequest, "no-store", false},
+		{"Error badge", "/progress/bar?percentage=abc&onError=badge", http.StatusOK, "no-store", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.queryString, nil)
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if cacheControl := w.Header().Get("Cache-Control"); cacheControl != tc.expectedCacheControl {
+				t.Errorf("Expected Cache-Control %q, got %q", tc.expectedCacheControl, cacheControl)
+			}
+			if etag := w.Header().Get("ETag"); (etag != "") != tc.expectETag {
+				t.Errorf("Expected ETag %v, got %q", tc.expectETag, etag)
+			}
+		})
+	}
+}
+
+func TestHandleChartNotModified(t *testing.T) {
+	router := gin.Default()
+	router.GET("/progress/bar", HandleProgressBar)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/progress/bar?percentage=50&width=200", nil)
+	router.ServeHTTP(w, req)
+	etag := w.Header().Get("ETag")
+
+	testCases := []struct {
+		name           string
+		queryString    string
+		ifNoneMatch    string
+		expectedStatus int
+	}{
+		{"Same ETag", "/progress/bar?percentage=50&width=200", etag, http.StatusNotModified},
+		{"Reordered parameters", "/progress/bar?width=200&percentage=50&height=", etag, http.StatusNotModified},
+		{"Weak ETag in a list", "/progress/bar?percentage=50&width=200", `"other", W/` + etag, http.StatusNotModified},
+		{"Wildcard", "/progress/bar?percentage=50&width=200", "*", http.StatusNotModified},
+		{"Other parameters", "/progress/bar?percentage=51&width=200", etag, http.StatusOK},
+		{"Other format", "/progress/bar?percentage=50&width=200&format=png", etag, http.StatusOK},
+		{"Stale ETag", "/progress/bar?percentage=50&width=200", `"stale"`, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.queryString, nil)
+			req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if tc.expectedStatus == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("Expected an empty body, got %q", w.Body.String())
+			}
+			if w.Header().Get("ETag") == "" {
+				t.Error("Expected an ETag")
+			}
+		})
+	}
+}
+
+func TestNormalizeParams(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"Sorted", "width=10&percentage=5", "percentage=5&width=10"},
+		{"Empty values dropped", "width=&percentage=5", "percentage=5"},
+		{"First value kept", "percentage=5&percentage=6", "percentage=5"},
+		{"Empty first value dropped", "width=&width=200&percentage=5", "percentage=5"},
+		{"Format dropped", "percentage=5&format=png", "percentage=5"},
+		{"Escaped", "title=a%26b", "title=a%26b"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			params, _ := url.ParseQuery(tc.query)
+			if actual := normalizeParams(params); actual != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	original := cachePolicy
+	defer SetCachePolicy(original)
+	SetCachePolicy(CachePolicy{})
+
+	router := gin.Default()
+	router.GET("/progress/bar", HandleProgressBar)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/progress/bar?percentage=50", nil)
+	router.ServeHTTP(w, req)
+
+	if cacheControl := w.Header().Get("Cache-Control"); cacheControl != "no-cache" {
+		t.Errorf("Expected Cache-Control %q, got %q", "no-cache", cacheControl)
+	}
+}
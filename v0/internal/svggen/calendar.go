@@ -4,119 +4,449 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"html/template"
-	"net/http"
+	"io"
+	"net/url"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
 const calendarChartTemplateStr = `
-	<svg width="370px" height="{{.Height}}px" xmlns="http://www.w3.org/2000/svg" font-family="Arial">
+	<svg width="370px" height="{{.Height}}px" xmlns="http://www.w3.org/2000/svg" font-family="Arial" role="img" aria-label="{{.A11y.Title}}">
+		<title>{{.A11y.Title}}</title>{{with .A11y.Desc}}<desc>{{.}}</desc>{{end}}{{.Style}}
 		<!-- Background with rounded corners and padding -->
-		<rect x="5" y="5" width="360px" height="{{add .Height -10}}px" fill="white" rx="15" />
+		<rect x="5" y="5" width="360px" height="{{add .Height -10}}px" fill="{{.BackgroundColor}}" rx="15" />
 
 		<!-- Header for month and year -->
-		<text x="180" y="35" font-size="20" text-anchor="middle" fill="black">{{.MonthName}} {{.Year}}</text>
+		<text x="180" y="35" font-size="20" text-anchor="middle" fill="{{.TextColor}}">{{.MonthName}} {{.Year}}</text>
 
 		{{- $startDay := .StartDay -}}
 		{{- $daysInMonth := .DaysInMonth -}}
-		{{- $progressDays := .ProgressDays -}}
+		{{- $dayStyles := .DayStyles -}}
+
+		<!-- Weekday names above the grid -->
+		{{- range .WeekdayLabels }}
+			<text x="{{.X}}" y="{{.Y}}" font-size="12" text-anchor="middle" fill="{{$.WeekdayColor}}">{{.Text}}</text>
+		{{- end -}}
 
 		<!-- Generating the grid -->
 		{{- range $i := seq 1 $daysInMonth -}}
 			{{- $positionIndex := add (add $i $startDay) -1 -}}
 			{{- $x := mod $positionIndex 7 -}}
 			{{- $y := div $positionIndex 7 -}}
-			{{- $isProgress := hasElem $progressDays $i -}}
-			<rect x="{{add (mult $x 50) 15}}" y="{{add (mult $y 50) 45}}" width="40" height="40" fill="{{if $isProgress}}#4c1{{else}}#f0f0f0{{end}}" stroke="#ddd" />
-			<text x="{{add (mult $x 50) 35}}" y="{{add (mult $y 50) 70}}" font-size="14" text-anchor="middle" fill="{{if $isProgress}}white{{else}}black{{end}}">{{$i}}</text>
+			{{- $style := index $dayStyles $i -}}
+			<rect x="{{add (mult $x 50) 15}}" y="{{add (mult $y 50) $.GridY}}" width="40" height="40" fill="{{$style.Fill}}" stroke="{{$.BorderColor}}" />
+			{{- range $style.Marks }}
+			<rect x="{{add (add (mult $x 50) 15) .X}}" y="{{add (add (mult $y 50) $.GridY) .Y}}" width="{{.Width}}" height="{{.Height}}" rx="{{.Radius}}" fill="{{.Color}}" />
+			{{- end }}
+			{{- if $style.Today }}
+			<rect x="{{add (mult $x 50) 16}}" y="{{add (mult $y 50) (add $.GridY 1)}}" width="38" height="38" fill="none" stroke="{{$.TodayColor}}" stroke-width="2" />
+			{{- end }}
+			<text x="{{add (mult $x 50) 35}}" y="{{add (mult $y 50) (add $.GridY 25)}}" font-size="14" text-anchor="middle" fill="{{$style.TextColor}}">{{$i}}</text>
+		{{- end }}
+
+		<!-- Legend for the categories -->
+		{{- range .Legend }}
+			<rect x="{{.X}}" y="{{.Y}}" width="12" height="12" rx="2" fill="{{.Color}}" />
+			<text x="{{add .X 17}}" y="{{add .Y 10}}" font-size="12" fill="{{$.TextColor}}">{{.Name}}</text>
 		{{- end }}
 	</svg>
 	`
 
+var calendarChartTemplate = template.Must(template.New("calendarChart").Funcs(template.FuncMap{
+	"seq":  seq,
+	"mod":  mod,
+	"div":  div,
+	"mult": multInt,
+	"add":  add,
+}).Parse(calendarChartTemplateStr))
+
+// Fill colors of calendar days
+const (
+	progressDayColor = "#4c1"
+	emptyDayColor    = "#f0f0f0"
+)
+
+// Ways of drawing the categories of a day
+const (
+	CategoryStyleSplit = "split" // The cell is split into one vertical stripe per category
+	CategoryStyleDots  = "dots"  // A dot per category is drawn along the bottom of the cell
+)
+
+// reservedCalendarParams are query parameters that cannot be used as category names.
+var reservedCalendarParams = []string{"year", "month", "progressDays", "days", "levels", "categories", "categoryStyle", "locale", "weekStart", "weekdays", "tz", "today", "theme", "title", "desc", "format", "onError"}
+
 func HandleCalendar(c *gin.Context) {
-	funcMap := template.FuncMap{
-		"seq":     seq,
-		"mod":     mod,
-		"div":     div,
-		"mult":    multInt,
-		"add":     add,
-		"hasElem": hasElem,
+	handleChart(c, renderCalendar, calendarUsesToday)
+}
+
+// calendarUsesToday reports whether the calendar takes anything from the current date:
+// the year or month when either is missing, today as the progress day when no days are
+// given, and the today outline.
+func calendarUsesToday(params url.Values) bool {
+	has := func(name string) bool { return queryOrDefault(params, name, "") != "" }
+	if !has("year") || !has("month") {
+		return true
+	}
+	if !has("progressDays") && !has("days") && !has("categories") {
+		return true
+	}
+	today, _ := strconv.ParseBool(queryOrDefault(params, "today", "false"))
+	return today
+}
+
+// CalendarCategory is a named set of days, such as the days a habit was kept.
+type CalendarCategory struct {
+	Name string
+	Days []int
+}
+
+// CalendarOptions configures a monthly calendar chart.
+type CalendarOptions struct {
+	Year          int
+	Month         time.Month
+	ProgressDays  []int              // Days of the month to mark as progress
+	DayCounts     map[int]int        // Count per day of the month, shaded with Levels intensities
+	Levels        int                // Number of intensity levels used for DayCounts
+	Categories    []CalendarCategory // Up to six categories, each drawn in its own color with a legend
+	CategoryStyle string             // CategoryStyleSplit (the default) or CategoryStyleDots
+	Locale        string             // Language of the month and weekday names, such as "de" or "pt-BR"
+	WeekStart     time.Weekday       // First day of each row of the grid
+	Weekdays      bool               // Show a row of weekday names above the grid
+	Today         int                // Day of the month to outline as today, 0 for none
+	Theme         string             // Name of the color theme, see Themes
+	Colors        ColorOverrides     // Colors replacing those of the theme
+	Title, Desc   string             // Accessible title and description, generated from the chart when empty
+}
+
+// DefaultCalendarOptions returns the options used when a parameter is not provided:
+// the current month with today marked as progress.
+func DefaultCalendarOptions() CalendarOptions {
+	return defaultCalendarOptionsAt(time.Now())
+}
+
+// defaultCalendarOptionsAt returns the default options for the month containing now,
+// in the timezone of now.
+func defaultCalendarOptionsAt(now time.Time) CalendarOptions {
+	return CalendarOptions{
+		Year:          now.Year(),
+		Month:         now.Month(),
+		ProgressDays:  []int{now.Day()},
+		Levels:        4,
+		CategoryStyle: CategoryStyleSplit,
+		Locale:        DefaultLocale,
+		WeekStart:     time.Sunday,
 	}
-	calendarChartTemplate := template.Must(template.New("calendarChart").Funcs(funcMap).Parse(calendarChartTemplateStr))
+}
+
+func renderCalendar(w io.Writer, params url.Values) error {
+	r := newParamReader(params)
+
+	// Every default is taken from the current time in the requested timezone
+	now := time.Now().In(readTimezone(r))
+	opts := defaultCalendarOptionsAt(now)
 
 	// Get year and month from query parameters with defaults to the current year and month
-	yearParam := c.DefaultQuery("year", strconv.Itoa(time.Now().Year()))
-	monthParam := c.DefaultQuery("month", strconv.Itoa(int(time.Now().Month())))
-
-	// Get progressDays from query parameter
-	progressDaysParam := c.DefaultQuery("progressDays", "")
-	var progressDays []int
-	if progressDaysParam != "" {
-		for _, dayStr := range strings.Split(progressDaysParam, ",") {
+	opts.Year = r.Int("year", opts.Year, 1, 9999)
+	opts.Month = time.Month(r.Int("month", int(opts.Month), 1, 12))
+
+	// Get progressDays from query parameter, defaulting to the current day if no days are provided
+	if r.Has("progressDays") || r.Has("days") || r.Has("categories") {
+		opts.ProgressDays = nil
+	}
+	if r.Has("progressDays") {
+		opts.ProgressDays = readDayList(r, "progressDays")
+	}
+
+	// Get per-day counts, formatted as day:count with a day on its own counting as 1
+	if r.Has("days") {
+		opts.DayCounts = map[int]int{}
+		for _, entry := range strings.Split(r.String("days", ""), ",") {
+			dayStr, countStr, hasCount := strings.Cut(entry, ":")
 			day, err := strconv.Atoi(dayStr)
-			if err != nil {
-				c.String(http.StatusBadRequest, fmt.Sprintf("Invalid progress day format: %s", dayStr))
-				return
+			if err != nil || day < 1 || day > 31 {
+				r.Fail("days", fmt.Sprintf("Invalid day format: %s", entry))
+				continue
 			}
-			progressDays = append(progressDays, day)
+			count := 1
+			if hasCount {
+				count, err = strconv.Atoi(countStr)
+				if err != nil || count < 0 {
+					r.Fail("days", fmt.Sprintf("Invalid day count format: %s", entry))
+					continue
+				}
+			}
+			opts.DayCounts[day] += count
 		}
-	} else {
-		// Default to the current day if no progressDays are provided
-		progressDays = append(progressDays, time.Now().Day())
 	}
+	opts.Levels = r.Int("levels", opts.Levels, 1, 9)
 
-	// Convert year and month to appropriate types
-	year, err := strconv.Atoi(yearParam)
-	if err != nil {
-		c.String(http.StatusBadRequest, "Invalid year format")
-		return
+	// Categories name other query parameters holding their days, e.g. categories=gym,read&gym=1,3&read=2
+	if r.Has("categories") {
+		for _, name := range strings.Split(r.String("categories", ""), ",") {
+			if name == "" || slices.Contains(reservedCalendarParams, name) || slices.Contains(colorParamNames(), name) {
+				r.Fail("categories", fmt.Sprintf("Invalid category name: %s (names must be non-empty and not a calendar parameter)", name))
+				continue
+			}
+			opts.Categories = append(opts.Categories, CalendarCategory{Name: name, Days: readDayList(r, name)})
+		}
+	} else if names := uncategorizedDayLists(params); len(names) > 0 {
+		// Days given under a name without the categories listing it would otherwise not be drawn
+		r.Fail("categories", fmt.Sprintf("Missing categories=%s for the days in %s", strings.Join(names, ","), strings.Join(names, ", ")))
 	}
+	opts.CategoryStyle = r.Enum("categoryStyle", opts.CategoryStyle, CategoryStyleSplit, CategoryStyleDots)
+	opts.Locale = readLocale(r, opts.Locale)
+	opts.WeekStart = readWeekStart(r, opts.WeekStart)
+	opts.Weekdays = r.Bool("weekdays", opts.Weekdays)
+	opts.Theme = readTheme(r, settings.Theme)
+	opts.Colors = readColorOverrides(r)
+	opts.Title, opts.Desc = readAccessibility(r)
 
-	monthInt, err := strconv.Atoi(monthParam)
-	if err != nil {
-		c.String(http.StatusBadRequest, "Invalid month format")
-		return
+	if r.Bool("today", false) && opts.Year == now.Year() && opts.Month == now.Month() {
+		opts.Today = now.Day()
 	}
 
-	month := time.Month(monthInt)
+	if err := r.Err(); err != nil {
+		return err
+	}
+	return RenderCalendar(w, opts)
+}
+
+// uncategorizedDayLists returns the sorted names of the parameters that are not calendar
+// parameters but hold a list of days, as a category does.
+func uncategorizedDayLists(params url.Values) []string {
+	var names []string
+	for name := range params {
+		value := queryOrDefault(params, name, "")
+		if value == "" || slices.Contains(reservedCalendarParams, name) || slices.Contains(colorParamNames(), name) {
+			continue
+		}
+		if strings.Trim(value, "0123456789,") == "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readDayList reads a comma separated list of days of the month.
+func readDayList(r *paramReader, name string) []int {
+	var days []int
+	if !r.Has(name) {
+		return days
+	}
+	for _, dayStr := range strings.Split(r.String(name, ""), ",") {
+		day, err := strconv.Atoi(dayStr)
+		if err != nil || day < 1 || day > 31 {
+			r.Fail(name, fmt.Sprintf("Invalid day format for %s: %s", name, dayStr))
+			continue
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+// calendarMark is a colored shape drawn on top of a day cell, positioned relative to the cell.
+type calendarMark struct {
+	X, Y, Width, Height, Radius int
+	Color                       string
+}
+
+// calendarDayStyle holds how a single day of the calendar is drawn.
+type calendarDayStyle struct {
+	Fill, TextColor string
+	Marks           []calendarMark
+	Today           bool
+}
+
+// RenderCalendar writes a monthly calendar SVG to w.
+// Returns a *ParamError if the month, levels, categories, category style, locale, week start, theme or colors are invalid.
+func RenderCalendar(w io.Writer, opts CalendarOptions) error {
+	year, month := opts.Year, opts.Month
 	if month < time.January || month > time.December {
-		c.String(http.StatusBadRequest, "Month must be between 1 and 12")
-		return
+		return &ParamError{"Month must be between 1 and 12"}
+	}
+	if len(opts.DayCounts) > 0 && (opts.Levels < 1 || opts.Levels > 9) {
+		return &ParamError{"Levels must be between 1 and 9"}
+	}
+	if len(opts.Categories) > len(seriesColors()) {
+		return &ParamError{fmt.Sprintf("At most %d categories are supported", len(seriesColors()))}
+	}
+	if opts.CategoryStyle == "" {
+		opts.CategoryStyle = CategoryStyleSplit
+	}
+	if opts.CategoryStyle != CategoryStyleSplit && opts.CategoryStyle != CategoryStyleDots {
+		return &ParamError{fmt.Sprintf("Invalid category style: %s (must be %s or %s)", opts.CategoryStyle, CategoryStyleSplit, CategoryStyleDots)}
 	}
 
-	// Calculate the first day of the month and number of days in the month
-	firstDayOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
-	startDay := int(firstDayOfMonth.Weekday())
-	lastDayOfMonth := firstDayOfMonth.AddDate(0, 1, -1)
-	daysInMonth := lastDayOfMonth.Day()
+	names, err := lookupLocale(opts.Locale)
+	if err != nil {
+		return err
+	}
+	if opts.WeekStart < time.Sunday || opts.WeekStart > time.Saturday {
+		return &ParamError{"Week start must be a day of the week"}
+	}
+	theme, err := resolveTheme(opts.Theme, opts.Colors)
+	if err != nil {
+		return err
+	}
+
+	startDay, daysInMonth := monthLayout(year, month, opts.WeekStart)
 
 	height := 310 // If the month has 5 weeks
 	if startDay+daysInMonth > 35 {
 		height = 360 // If the month has 6 weeks
 	}
 
+	// The weekday row pushes the grid down
+	gridY := 45
+	var weekdayLabels []heatmapLabel
+	if opts.Weekdays {
+		for column := range 7 {
+			weekday := time.Weekday((int(opts.WeekStart) + column) % 7)
+			weekdayLabels = append(weekdayLabels, heatmapLabel{X: column*50 + 35, Y: 60, Text: names.WeekdayName(weekday)})
+		}
+		gridY += 20
+		height += 20
+	}
+
+	categoryNames := make([]string, len(opts.Categories))
+	for i, category := range opts.Categories {
+		categoryNames[i] = category.Name
+	}
+	legend := layoutLegend(categoryNames, theme.series(), 15, 355, height-15)
+	if len(legend) > 0 {
+		height += legend[len(legend)-1].Y - (height - 15) + 25
+	}
+
+	dayStyles := calendarDayStyles(opts, daysInMonth, theme)
+	completed := 0
+	for _, style := range dayStyles[1:] {
+		if style.Fill != theme.Empty || len(style.Marks) > 0 {
+			completed++
+		}
+	}
+	var categoryCounts []string
+	for _, category := range opts.Categories {
+		days := 0
+		for day := 1; day <= daysInMonth; day++ {
+			if hasElem(category.Days, day) {
+				days++
+			}
+		}
+		categoryCounts = append(categoryCounts, fmt.Sprintf("%s on %d of %d days", category.Name, days, daysInMonth))
+	}
+	a11y := describe(opts.Title, opts.Desc,
+		fmt.Sprintf("%s %d, %d of %d days completed", names.MonthName(month), year, completed, daysInMonth),
+		strings.Join(categoryCounts, ", "))
+
 	// Prepare data for the template
 	data := struct {
-		Year, Month, StartDay, DaysInMonth int
-		MonthName                          string
-		ProgressDays                       []int
-		Height                             int
+		Year, Month, StartDay, DaysInMonth  int
+		MonthName, WeekdayColor, TodayColor string
+		BackgroundColor, TextColor          string
+		BorderColor                         string
+		Style                               template.HTML
+		A11y                                accessibility
+		DayStyles                           []calendarDayStyle
+		WeekdayLabels                       []heatmapLabel
+		Legend                              []legendEntry
+		Height, GridY                       int
 	}{
-		Year:         year,
-		Month:        int(month),
-		MonthName:    month.String(),
-		StartDay:     startDay,
-		DaysInMonth:  daysInMonth,
-		ProgressDays: progressDays,
-		Height:       height,
-	}
-
-	// Execute the template and write the response
-	c.Writer.Header().Set("Content-Type", "image/svg+xml")
-	err = calendarChartTemplate.Execute(c.Writer, data)
-	if err != nil {
-		c.String(http.StatusInternalServerError, fmt.Sprintf("Error rendering calendar: %v", err))
-		return
+		Year:          year,
+		Month:         int(month),
+		MonthName:     names.MonthName(month),
+		WeekdayColor:  theme.MutedText,
+		StartDay:      startDay,
+		DaysInMonth:   daysInMonth,
+		DayStyles:     dayStyles,
+		Legend:        legend,
+		WeekdayLabels: weekdayLabels,
+		TodayColor:    theme.Text,
+		Height:        height,
+		GridY:         gridY,
+		Style:         theme.Style(),
+		A11y:          a11y,
+
+		BackgroundColor: theme.Background,
+		TextColor:       theme.Text,
+		BorderColor:     theme.Border,
 	}
+
+	// Execute the template and write the result
+	if err := calendarChartTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("error rendering calendar: %w", err)
+	}
+	return nil
+}
+
+// calendarDayStyles returns the style of every day of the month, indexed by day (index 0 is unused).
+// Categories take precedence over counts, and counts over progress days.
+func calendarDayStyles(opts CalendarOptions, daysInMonth int, theme theme) []calendarDayStyle {
+	maxCount := 0
+	for _, count := range opts.DayCounts {
+		maxCount = max(maxCount, count)
+	}
+	ramp := theme.ramp(max(opts.Levels, 1))
+	palette := theme.series()
+
+	styles := make([]calendarDayStyle, daysInMonth+1)
+	for day := 1; day <= daysInMonth; day++ {
+		style := calendarDayStyle{Fill: theme.Empty, TextColor: theme.Text, Today: day == opts.Today}
+		if count := opts.DayCounts[day]; count > 0 {
+			style.Fill = heatmapColor(count, maxCount, ramp)
+		} else if hasElem(opts.ProgressDays, day) {
+			style.Fill = theme.Progress
+		}
+		if style.Fill != theme.Empty {
+			style.TextColor = theme.OnFill
+		}
+
+		var colors []string
+		for i, category := range opts.Categories {
+			if hasElem(category.Days, day) {
+				colors = append(colors, palette[i%len(palette)])
+			}
+		}
+		if len(colors) > 0 {
+			style.Marks = categoryMarks(colors, opts.CategoryStyle)
+			if opts.CategoryStyle == CategoryStyleSplit {
+				style.TextColor = theme.OnFill
+			}
+		}
+		styles[day] = style
+	}
+	return styles
+}
+
+// categoryMarks returns the shapes drawn on a 40x40 day cell for the given category colors.
+func categoryMarks(colors []string, categoryStyle string) []calendarMark {
+	marks := make([]calendarMark, len(colors))
+	if categoryStyle == CategoryStyleDots {
+		const dotSize, dotStep = 6, 8
+		x := (40 - (len(colors)-1)*dotStep - dotSize) / 2
+		for i, color := range colors {
+			marks[i] = calendarMark{X: x + i*dotStep, Y: 30, Width: dotSize, Height: dotSize, Radius: dotSize / 2, Color: color}
+		}
+		return marks
+	}
+
+	// Split the cell into stripes, giving any remaining pixels to the last stripe
+	stripe := 40 / len(colors)
+	for i, color := range colors {
+		marks[i] = calendarMark{X: i * stripe, Width: stripe, Height: 40, Color: color}
+	}
+	marks[len(marks)-1].Width = 40 - (len(colors)-1)*stripe
+	return marks
+}
+
+// monthLayout returns the column of the first day of the month in a week starting on
+// weekStart (0 for the first column) and the number of days in the month.
+func monthLayout(year int, month time.Month, weekStart time.Weekday) (startDay, daysInMonth int) {
+	// Calculate the first day of the month and number of days in the month
+	firstDayOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	lastDayOfMonth := firstDayOfMonth.AddDate(0, 1, -1)
+	return weekdayColumn(firstDayOfMonth.Weekday(), weekStart), lastDayOfMonth.Day()
 }
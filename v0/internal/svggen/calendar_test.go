@@ -1,6 +1,7 @@
 package svggen
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -15,20 +16,24 @@ func TestHandleCalendar(t *testing.T) {
 	router := gin.Default()
 	router.GET("/calendar", HandleCalendar)
 
+	kiritimati, _ := time.LoadLocation("Pacific/Kiritimati") // UTC+14, usually a day ahead of the server
+	farAhead := time.Now().In(kiritimati)
+
 	testCases := []struct {
-		name           string
-		queryString    string
-		expectedStatus int
-		expectInBody   []string
+		name            string
+		queryString     string
+		expectedStatus  int
+		expectInBody    []string
+		expectNotInBody []string
 	}{
 		{
 			name:           "Valid parameters - Spot Checks",
 			queryString:    "/calendar?year=2023&month=1&progressDays=1,15",
 			expectedStatus: http.StatusOK,
 			expectInBody: []string{
-				`<svg width="370px" height="310px" xmlns="http://www.w3.org/2000/svg" font-family="Arial">`, // Height for 5wk month
-				`<rect x="5" y="5" width="360px" height="300px" fill="white" rx="15" />`,                    // Background
-				`<text x="180" y="35" font-size="20" text-anchor="middle" fill="black">January 2023</text>`, // Header
+				`<svg width="370px" height="310px" xmlns="http://www.w3.org/2000/svg" font-family="Arial" role="img"`, // Height for 5wk month
+				`<rect x="5" y="5" width="360px" height="300px" fill="white" rx="15" />`,                              // Background
+				`<text x="180" y="35" font-size="20" text-anchor="middle" fill="black">January 2023</text>`,           // Header
 				// Check for a few specific days, including one that is marked as progress and one that is not
 				`<rect x="15" y="45" width="40" height="40" fill="#4c1" stroke="#ddd" />`,       // Day 1 with progress
 				`<text x="35" y="70" font-size="14" text-anchor="middle" fill="white">1</text>`, // Text for Day 1
@@ -41,7 +46,7 @@ func TestHandleCalendar(t *testing.T) {
 			queryString:    "/calendar?year=2023&month=4",
 			expectedStatus: http.StatusOK,
 			expectInBody: []string{
-				`<svg width="370px" height="360px" xmlns="http://www.w3.org/2000/svg" font-family="Arial">`,
+				`<svg width="370px" height="360px" xmlns="http://www.w3.org/2000/svg" font-family="Arial" role="img"`,
 			},
 		},
 		{
@@ -49,7 +54,7 @@ func TestHandleCalendar(t *testing.T) {
 			queryString:    "/calendar?year=2024&month=8",
 			expectedStatus: http.StatusOK,
 			expectInBody: []string{
-				`<svg width="370px" height="310px" xmlns="http://www.w3.org/2000/svg" font-family="Arial">`,
+				`<svg width="370px" height="310px" xmlns="http://www.w3.org/2000/svg" font-family="Arial" role="img"`,
 			},
 		},
 		{
@@ -57,7 +62,7 @@ func TestHandleCalendar(t *testing.T) {
 			queryString:    "/calendar?year=2023&month=1&progressDays=1,15",
 			expectedStatus: http.StatusOK,
 			expectInBody: []string{
-				`<svg width="370px" height="310px" xmlns="http://www.w3.org/2000/svg" font-family="Arial">`,
+				`<svg width="370px" height="310px" xmlns="http://www.w3.org/2000/svg" font-family="Arial" role="img"`,
 				`<rect x="5" y="5" width="360px" height="300px" fill="white" rx="15" />`,
 				`<text x="180" y="35" font-size="20" text-anchor="middle" fill="black">January 2023</text><rect x="15" y="45" width="40" height="40" fill="#4c1" stroke="#ddd" />`,
 				`<text x="35" y="70" font-size="14" text-anchor="middle" fill="white">1</text><rect x="65" y="45" width="40" height="40" fill="#f0f0f0" stroke="#ddd" />`,
@@ -106,6 +111,210 @@ func TestHandleCalendar(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectInBody:   []string{"Month must be between 1 and 12"},
 		},
+		{
+			name:           "Day counts",
+			queryString:    "/calendar?year=2023&month=1&days=3:2,4:8,10&levels=4",
+			expectedStatus: http.StatusOK,
+			expectInBody: []string{
+				`<rect x="115" y="45" width="40" height="40" fill="#99F255" stroke="#ddd" />`,    // Day 3, lowest level
+				`<text x="135" y="70" font-size="14" text-anchor="middle" fill="white">3</text>`, // Text for Day 3
+				`<rect x="165" y="45" width="40" height="40" fill="#216E39" stroke="#ddd" />`,    // Day 4, highest level
+				`<rect x="15" y="45" width="40" height="40" fill="#f0f0f0" stroke="#ddd" />`,     // Day 1 is not marked by default
+			},
+		},
+		{
+			name:           "Categories split the cell",
+			queryString:    "/calendar?year=2023&month=1&categories=gym,read&gym=1,2&read=2",
+			expectedStatus: http.StatusOK,
+			expectInBody: []string{
+				`<svg width="370px" height="335px" xmlns="http://www.w3.org/2000/svg" font-family="Arial" role="img"`,
+				`<rect x="15" y="45" width="40" height="40" rx="0" fill="#44CC11" />`,  // Day 1 gym
+				`<rect x="65" y="45" width="20" height="40" rx="0" fill="#44CC11" />`,  // Day 2 gym
+				`<rect x="85" y="45" width="20" height="40" rx="0" fill="#3B82F6" />`,  // Day 2 read
+				`<rect x="15" y="295" width="12" height="12" rx="2" fill="#44CC11" />`, // Legend swatch
+				`<text x="32" y="305" font-size="12" fill="black">gym</text>`,          // Legend name
+			},
+		},
+		{
+			name:           "Categories as dots",
+			queryString:    "/calendar?year=2023&month=1&categories=gym,read&gym=2&read=2&categoryStyle=dots",
+			expectedStatus: http.StatusOK,
+			expectInBody: []string{
+				`<rect x="78" y="75" width="6" height="6" rx="3" fill="#44CC11" />`,
+				`<rect x="86" y="75" width="6" height="6" rx="3" fill="#3B82F6" />`,
+				`<text x="85" y="70" font-size="14" text-anchor="middle" fill="black">2</text>`,
+			},
+		},
+		{
+			name:           "Invalid day count",
+			queryString:    "/calendar?year=2023&month=1&days=3:x",
+			expectedStatus: http.StatusBadRequest,
+			expectInBody:   []string{"Invalid day count format: 3:x"},
+		},
+		{
+			name:           "Reserved category name",
+			queryString:    "/calendar?year=2023&month=1&categories=month",
+			expectedStatus: http.StatusBadRequest,
+			expectInBody:   []string{`Invalid category name: month`},
+		},
+		{
+			name:           "Too many categories",
+			queryString:    "/calendar?year=2023&month=1&categories=a,b,c,d,e,f,g",
+			expectedStatus: http.StatusBadRequest,
+			expectInBody:   []string{"At most 6 categories are supported"},
+		},
+		{
+			name:           "Category days without categories",
+			queryString:    "/calendar?year=2023&month=1&gym=1,3,5&read=2,4",
+			expectedStatus: http.StatusBadRequest,
+			expectInBody:   []string{"Missing categories=gym,read for the days in gym, read"},
+		},
+		{
+			name:           "Unrelated parameters are not categories",
+			queryString:    "/calendar?year=2023&month=1&progressDays=1&ref=readme",
+			expectedStatus: http.StatusOK,
+			expectInBody:   []string{"<svg"},
+		},
+		{
+			name:           "Invalid category style",
+			queryString:    "/calendar?year=2023&month=1&categories=gym&categoryStyle=stars",
+			expectedStatus: http.StatusBadRequest,
+			expectInBody:   []string{"Invalid categoryStyle: stars (must be split or dots)"},
+		},
+		{
+			name:           "Locale, Monday week start and weekday names",
+			queryString:    "/calendar?year=2024&month=9&progressDays=1&locale=de-AT&weekStart=monday&weekdays=true",
+			expectedStatus: http.StatusOK,
+			expectInBody: []string{
+				`<svg width="370px" height="380px" xmlns="http://www.w3.org/2000/svg" font-family="Arial" role="img"`, // 6 weeks plus the weekday row
+				`<text x="180" y="35" font-size="20" text-anchor="middle" fill="black">September 2024</text>`,
+				`<text x="35" y="60" font-size="12" text-anchor="middle" fill="#7A7A7A">Mo</text>`,
+				`<text x="335" y="60" font-size="12" text-anchor="middle" fill="#7A7A7A">So</text>`,
+				`<rect x="315" y="65" width="40" height="40" fill="#4c1" stroke="#ddd" />`,    // Sunday 1 in the last column
+				`<rect x="15" y="315" width="40" height="40" fill="#f0f0f0" stroke="#ddd" />`, // Monday 30 in the sixth row
+			},
+		},
+		{
+			name:           "Localized month name",
+			queryString:    "/calendar?year=2024&month=3&locale=es",
+			expectedStatus: http.StatusOK,
+			expectInBody:   []string{`>Marzo 2024</text>`, `aria-label="Marzo 2024, `, `<title>Marzo 2024, `},
+		},
+		{
+			name:           "Saturday week start changes the row count",
+			queryString:    "/calendar?year=2024&month=8&weekStart=saturday",
+			expectedStatus: http.StatusOK,
+			expectInBody: []string{
+				`<svg width="370px" height="360px" xmlns="http://www.w3.org/2000/svg" font-family="Arial" role="img"`,
+				`<rect x="265" y="45" width="40" height="40" fill="#f0f0f0" stroke="#ddd" />`, // Thursday 1 in the sixth column
+			},
+		},
+		{
+			name:           "Unsupported locale",
+			queryString:    "/calendar?year=2024&month=3&locale=tlh",
+			expectedStatus: http.StatusBadRequest,
+			expectInBody:   []string{"Unsupported locale: tlh"},
+		},
+		{
+			name:           "Invalid week start",
+			queryString:    "/calendar?year=2024&month=3&weekStart=friday",
+			expectedStatus: http.StatusBadRequest,
+			expectInBody:   []string{"Invalid weekStart: friday"},
+		},
+		{
+			name:           "Defaults follow the timezone",
+			queryString:    "/calendar?tz=Pacific/Kiritimati",
+			expectedStatus: http.StatusOK,
+			expectInBody: []string{
+				fmt.Sprintf(`>%s %d</text>`, farAhead.Month(), farAhead.Year()),
+				fmt.Sprintf(`text-anchor="middle" fill="white">%d</text>`, farAhead.Day()),
+			},
+		},
+		{
+			name:           "Today outline",
+			queryString:    fmt.Sprintf("/calendar?tz=Pacific/Kiritimati&today=true&progressDays=%d", farAhead.Day()%28+1),
+			expectedStatus: http.StatusOK,
+			expectInBody: []string{
+				`width="38" height="38" fill="none" stroke="black" stroke-width="2" />`,
+				fmt.Sprintf(`text-anchor="middle" fill="black">%d</text>`, farAhead.Day()), // Today is not a progress day
+			},
+		},
+		{
+			name:           "No today outline outside the current month",
+			queryString:    "/calendar?year=2000&month=1&today=true",
+			expectedStatus: http.StatusOK,
+			expectNotInBody: []string{
+				`fill="none"`,
+			},
+		},
+		{
+			name:           "Unknown timezone",
+			queryString:    "/calendar?tz=Mars/Olympus_Mons",
+			expectedStatus: http.StatusBadRequest,
+			expectInBody:   []string{"Unknown timezone: Mars/Olympus_Mons"},
+		},
+		{
+			name:           "Dark theme",
+			queryString:    "/calendar?year=2023&month=1&progressDays=1&theme=dark",
+			expectedStatus: http.StatusOK,
+			expectInBody: []string{
+				`<rect x="5" y="5" width="360px" height="300px" fill="#0D1117" rx="15" />`,
+				`<text x="180" y="35" font-size="20" text-anchor="middle" fill="#E6EDF3">January 2023</text>`,
+				`<rect x="65" y="45" width="40" height="40" fill="#161B22" stroke="#30363D" />`,
+			},
+			expectNotInBody: []string{"<style>"},
+		},
+		{
+			name:           "Auto theme adds a dark color scheme",
+			queryString:    "/calendar?year=2023&month=1&theme=auto",
+			expectedStatus: http.StatusOK,
+			expectInBody: []string{
+				`</title><style>@media (prefers-color-scheme: dark){`,
+				`:not(text)[fill="white"]{fill:#0D1117}`,
+				`:not(text)[fill="#f0f0f0"]{fill:#161B22}`,
+				`<rect x="5" y="5" width="360px" height="300px" fill="white" rx="15" />`,
+			},
+		},
+		{
+			name:           "Color overrides",
+			queryString:    "/calendar?year=2023&month=1&progressDays=1&active=purple&empty=%23EEE&background=ivory",
+			expectedStatus: http.StatusOK,
+			expectInBody: []string{
+				`<rect x="5" y="5" width="360px" height="300px" fill="ivory" rx="15" />`,
+				fmt.Sprintf(`<rect x="15" y="45" width="40" height="40" fill="%s" stroke="#ddd" />`, Colors.Purple),
+				`<rect x="65" y="45" width="40" height="40" fill="#EEE" stroke="#ddd" />`,
+			},
+		},
+		{
+			name:           "Color parameters are not category names",
+			queryString:    "/calendar?categories=active&active=1",
+			expectedStatus: http.StatusBadRequest,
+			expectInBody:   []string{"Invalid category name: active"},
+		},
+		{
+			name:           "Generated title and desc",
+			queryString:    "/calendar?year=2023&month=1&progressDays=1,15&categories=gym&gym=2,3",
+			expectedStatus: http.StatusOK,
+			expectInBody: []string{
+				`role="img" aria-label="January 2023, 4 of 31 days completed">`,
+				`<title>January 2023, 4 of 31 days completed</title><desc>gym on 2 of 31 days</desc>`,
+			},
+		},
+		{
+			name:           "Title and desc override the generated text",
+			queryString:    "/calendar?year=2023&month=1&title=Gym%20%3Cstreak%3E&desc=Every%20visit%20in%20January",
+			expectedStatus: http.StatusOK,
+			expectInBody: []string{
+				`aria-label="Gym &lt;streak&gt;">`,
+				`<title>Gym &lt;streak&gt;</title><desc>Every visit in January</desc>`,
+			},
+		},
+		{
+			name:           "Unknown theme",
+			queryString:    "/calendar?theme=neon",
+			expectedStatus: http.StatusBadRequest,
+			expectInBody:   []string{"Unknown theme: neon (must be one of auto, dark, default, high-contrast, solarized, solarized-dark)"},
+		},
 		{
 			name:           "Default to current year and month",
 			queryString:    "/calendar",
@@ -131,6 +340,11 @@ func TestHandleCalendar(t *testing.T) {
 					t.Errorf("Expected to find %s in response body", str)
 				}
 			}
+			for _, str := range tc.expectNotInBody {
+				if strings.Contains(body, str) {
+					t.Errorf("Expected not to find %s in response body", str)
+				}
+			}
 		})
 	}
 }
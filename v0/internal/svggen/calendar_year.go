@@ -0,0 +1,258 @@
+package svggen
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"html/template"
+	"io"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const yearCalendarTemplateStr = `
+	<svg width="{{.Width}}px" height="{{.Height}}px" xmlns="http://www.w3.org/2000/svg" font-family="Arial" role="img" aria-label="{{.A11y.Title}}">
+		<title>{{.A11y.Title}}</title>{{with .A11y.Desc}}<desc>{{.}}</desc>{{end}}{{.Style}}
+		<rect x="0" y="0" width="{{.Width}}px" height="{{.Height}}px" fill="{{.BackgroundColor}}" rx="6" />
+		<text x="{{.GridX}}" y="14" font-size="12" fill="{{$.TextColor}}">{{.Year}}</text>
+		{{- range .MonthLabels }}
+		<text x="{{.X}}" y="{{$.MonthLabelY}}" font-size="9" fill="{{$.TextColor}}">{{.Text}}</text>
+		{{- end }}
+		{{- range .WeekdayLabels }}
+		<text x="{{$.Padding}}" y="{{.Y}}" font-size="9" dominant-baseline="central" fill="{{$.TextColor}}">{{.Text}}</text>
+		{{- end }}
+		{{- range .Cells }}
+		<rect class="heatmapCell" x="{{.X}}" y="{{.Y}}" width="{{$.CellSize}}" height="{{$.CellSize}}" rx="2" fill="{{.Color}}" data-count="{{.Count}}" />
+		{{- end }}
+		<text x="{{.LegendX}}" y="{{.LegendTextY}}" font-size="9" text-anchor="end" dominant-baseline="central" fill="{{$.TextColor}}">{{.Less}}</text>
+		{{- range .Legend }}
+		<rect x="{{.X}}" y="{{$.LegendY}}" width="{{$.CellSize}}" height="{{$.CellSize}}" rx="2" fill="{{.Color}}" />
+		{{- end }}
+		<text x="{{.LegendEndX}}" y="{{.LegendTextY}}" font-size="9" dominant-baseline="central" fill="{{$.TextColor}}">{{.More}}</text>
+	</svg>
+	`
+
+var yearCalendarTemplate = template.Must(template.New("yearCalendar").Parse(yearCalendarTemplateStr))
+
+// Layout of the year calendar in pixels
+const (
+	heatmapCellSize = 10
+	heatmapStep     = 13 // Cell size plus the gap between cells
+	heatmapPadding  = 8
+	heatmapLabelsX  = 30 // Width reserved for the weekday labels
+	heatmapTop      = 34 // Space above the grid for the year and month labels
+)
+
+// DayCount is the count recorded for one day of a year calendar.
+type DayCount struct {
+	Date  time.Time
+	Count int
+}
+
+// YearCalendarOptions configures a year calendar heatmap.
+type YearCalendarOptions struct {
+	Year        int
+	Counts      []DayCount     // Counts on the same date are added up, dates outside Year are ignored
+	Levels      int            // Number of intensity levels used for days with a count
+	Locale      string         // Language of the month, weekday and legend labels, such as "de" or "pt-BR"
+	WeekStart   time.Weekday   // Weekday of the top row
+	Theme       string         // Name of the color theme, see Themes
+	Colors      ColorOverrides // Colors replacing those of the theme
+	Title, Desc string         // Accessible title and description, generated from the chart when empty
+}
+
+// DefaultYearCalendarOptions returns the options used when a parameter is not provided:
+// the current year with no counts and four intensity levels.
+func DefaultYearCalendarOptions() YearCalendarOptions {
+	return YearCalendarOptions{Year: time.Now().Year(), Levels: 4, Locale: DefaultLocale, WeekStart: time.Sunday}
+}
+
+type heatmapCell struct {
+	X, Y, Count int
+	Color       string
+}
+
+type heatmapLabel struct {
+	X, Y int
+	Text string
+}
+
+func HandleCalendarYear(c *gin.Context) {
+	handleChart(c, renderCalendarYear, calendarYearUsesToday)
+}
+
+// calendarYearUsesToday reports whether the heatmap defaults to the current year.
+func calendarYearUsesToday(params url.Values) bool {
+	return queryOrDefault(params, "year", "") == ""
+}
+
+func renderCalendarYear(w io.Writer, params url.Values) error {
+	opts := DefaultYearCalendarOptions()
+	r := newParamReader(params)
+
+	opts.Year = r.Int("year", time.Now().In(readTimezone(r)).Year(), 1, 9999)
+	opts.Levels = r.Int("levels", opts.Levels, 1, 9)
+	opts.Locale = readLocale(r, opts.Locale)
+	opts.WeekStart = readWeekStart(r, opts.WeekStart)
+	opts.Theme = readTheme(r, settings.Theme)
+	opts.Colors = readColorOverrides(r)
+	opts.Title, opts.Desc = readAccessibility(r)
+
+	// Counts are a comma separated list of YYYY-MM-DD:count, a date on its own counts as 1
+	if r.Has("counts") {
+		for _, entry := range strings.Split(r.String("counts", ""), ",") {
+			dateStr, countStr, hasCount := strings.Cut(entry, ":")
+			date, err := time.Parse(time.DateOnly, dateStr)
+			if err != nil {
+				r.Fail("counts", fmt.Sprintf("Invalid count date: %s", entry))
+				continue
+			}
+			count := 1
+			if hasCount {
+				count, err = strconv.Atoi(countStr)
+				if err != nil || count < 0 {
+					r.Fail("counts", fmt.Sprintf("Invalid count format: %s", entry))
+					continue
+				}
+			}
+			opts.Counts = append(opts.Counts, DayCount{Date: date, Count: count})
+		}
+	}
+
+	if err := r.Err(); err != nil {
+		return err
+	}
+	return RenderYearCalendar(w, opts)
+}
+
+// RenderYearCalendar writes a GitHub style contribution heatmap of a whole year to w.
+// Each column is a week starting on opts.WeekStart and each day is shaded by its count.
+// Returns a *ParamError if Levels is out of range or the locale, week start, theme or colors are invalid.
+func RenderYearCalendar(w io.Writer, opts YearCalendarOptions) error {
+	if opts.Levels < 1 || opts.Levels > 9 {
+		return &ParamError{"Levels must be between 1 and 9"}
+	}
+	names, err := lookupLocale(opts.Locale)
+	if err != nil {
+		return err
+	}
+	if opts.WeekStart < time.Sunday || opts.WeekStart > time.Saturday {
+		return &ParamError{"Week start must be a day of the week"}
+	}
+	theme, err := resolveTheme(opts.Theme, opts.Colors)
+	if err != nil {
+		return err
+	}
+
+	// Sum the counts per day of the year
+	counts := map[int]int{}
+	maxCount, total := 0, 0
+	for _, dc := range opts.Counts {
+		if dc.Date.Year() != opts.Year {
+			continue
+		}
+		counts[dc.Date.YearDay()] += dc.Count
+		total += dc.Count
+		maxCount = max(maxCount, counts[dc.Date.YearDay()])
+	}
+	ramp := theme.ramp(opts.Levels)
+
+	// Weeks start on the week start on or before the 1st of January
+	startDay, _ := monthLayout(opts.Year, time.January, opts.WeekStart)
+	gridX := heatmapPadding + heatmapLabelsX
+	gridY := heatmapTop
+
+	var cells []heatmapCell
+	var monthLabels []heatmapLabel
+	dayOfYear := 1
+	for month := time.January; month <= time.December; month++ {
+		_, daysInMonth := monthLayout(opts.Year, month, opts.WeekStart)
+		for day := 1; day <= daysInMonth; day++ {
+			position := startDay + dayOfYear - 1
+			x := gridX + (position/7)*heatmapStep
+			y := gridY + (position%7)*heatmapStep
+			if day == 1 {
+				monthLabels = append(monthLabels, heatmapLabel{X: x, Text: names.ShortMonthName(month)})
+			}
+			count := counts[dayOfYear]
+			color := theme.Empty
+			if count > 0 {
+				color = heatmapColor(count, maxCount, ramp)
+			}
+			cells = append(cells, heatmapCell{X: x, Y: y, Count: count, Color: color})
+			dayOfYear++
+		}
+	}
+	weeks := (startDay+dayOfYear-2)/7 + 1
+
+	var weekdayLabels []heatmapLabel
+	for _, weekday := range []time.Weekday{time.Monday, time.Wednesday, time.Friday} {
+		weekdayLabels = append(weekdayLabels, heatmapLabel{
+			Y:    gridY + weekdayColumn(weekday, opts.WeekStart)*heatmapStep + heatmapCellSize/2,
+			Text: names.WeekdayName(weekday),
+		})
+	}
+
+	width := gridX + weeks*heatmapStep + heatmapPadding
+	legendY := gridY + 7*heatmapStep + heatmapPadding
+	legend := make([]heatmapCell, opts.Levels+1)
+	// Leave room for the "More" label after the legend, however long it is in the locale
+	moreWidth := max(30, int(math.Ceil(textWidth(names.More, 9, false)))+5)
+	legendX := width - heatmapPadding - moreWidth - len(legend)*heatmapStep
+	for i := range legend {
+		legend[i] = heatmapCell{X: legendX + i*heatmapStep, Color: theme.Empty}
+		if i > 0 {
+			legend[i].Color = ramp[i-1]
+		}
+	}
+
+	data := struct {
+		Year, Width, Height, GridX, Padding, CellSize, MonthLabelY int
+		LegendX, LegendY, LegendTextY, LegendEndX                  int
+		BackgroundColor, TextColor, Less, More                     string
+		Style                                                      template.HTML
+		A11y                                                       accessibility
+		Cells, Legend                                              []heatmapCell
+		MonthLabels, WeekdayLabels                                 []heatmapLabel
+	}{
+		BackgroundColor: theme.Background,
+		TextColor:       theme.Text,
+		Less:            names.Less,
+		More:            names.More,
+		Style:           theme.Style(),
+		A11y:            describe(opts.Title, opts.Desc, fmt.Sprintf("%d: %d days with activity, %d in total", opts.Year, len(counts), total), ""),
+
+		Year:          opts.Year,
+		Width:         width,
+		Height:        legendY + heatmapCellSize + heatmapPadding,
+		GridX:         gridX,
+		Padding:       heatmapPadding,
+		CellSize:      heatmapCellSize,
+		MonthLabelY:   gridY - 4,
+		LegendX:       legendX - 4,
+		LegendY:       legendY,
+		LegendTextY:   legendY + heatmapCellSize/2,
+		LegendEndX:    legendX + len(legend)*heatmapStep + 1,
+		Cells:         cells,
+		Legend:        legend,
+		MonthLabels:   monthLabels,
+		WeekdayLabels: weekdayLabels,
+	}
+
+	if err := yearCalendarTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("error rendering year calendar: %w", err)
+	}
+	return nil
+}
+
+// heatmapColor buckets a count into one of the ramp colors, scaled linearly so the
+// largest count gets the darkest color. Days without a count use the empty color.
+func heatmapColor(count, maxCount int, ramp []string) string {
+	if count <= 0 || maxCount <= 0 {
+		return emptyDayColor
+	}
+	level := (count*len(ramp) + maxCount - 1) / maxCount // Ceiling of count / maxCount * levels
+	return ramp[clamp(level, 1, len(ramp))-1]
+}
@@ -0,0 +1,164 @@
+package svggen
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleCalendarYear(t *testing.T) {
+	router := gin.Default()
+	router.GET("/calendar/year", HandleCalendarYear)
+
+	ramp := colorRamp([]string{Colors.LightGreen, Colors.Green, Colors.DarkGreen}, 4)
+
+	testCases := []struct {
+		name           string
+		queryString    string
+		expectedStatus int
+		expectedCells  int
+		expectInBody   []string
+	}{
+		{
+			name:           "Leap year starting on Monday",
+			queryString:    "/calendar/year?year=2024&counts=2024-01-01:1,2024-03-05:8,2024-03-05:4,2024-07-04",
+			expectedStatus: http.StatusOK,
+			expectedCells:  366,
+			expectInBody: []string{
+				`<svg width="735px" height="151px" xmlns="http://www.w3.org/2000/svg" font-family="Arial" role="img"`,
+				`<title>2024: 3 days with activity, 14 in total</title>`,
+				`<text x="38" y="14" font-size="12" fill="black">2024</text>`,
+				fmt.Sprintf(`<rect class="heatmapCell" x="38" y="47" width="10" height="10" rx="2" fill="%s" data-count="1" />`, ramp[0]),       // Mon 1 Jan
+				fmt.Sprintf(`<rect class="heatmapCell" x="155" y="60" width="10" height="10" rx="2" fill="%s" data-count="12" />`, ramp[3]),     // Tue 5 Mar, summed
+				fmt.Sprintf(`<rect class="heatmapCell" x="38" y="60" width="10" height="10" rx="2" fill="%s" data-count="0" />`, emptyDayColor), // Tue 2 Jan
+				`>Mar</text>`,
+				`>Wed</text>`,
+				`>Less</text>`,
+				`>More</text>`,
+			},
+		},
+		{
+			name:           "Long legend labels fit",
+			queryString:    "/calendar/year?year=2024&locale=fi",
+			expectedStatus: http.StatusOK,
+			expectedCells:  366,
+			expectInBody: []string{
+				`<svg width="735px"`,
+				`<text x="677" y="138" font-size="9" dominant-baseline="central" fill="black">Enemmän</text>`,
+			},
+		},
+		{
+			name:           "Locale and Monday week start",
+			queryString:    "/calendar/year?year=2024&locale=fr&weekStart=monday&counts=2024-01-01",
+			expectedStatus: http.StatusOK,
+			expectedCells:  366,
+			expectInBody: []string{
+				fmt.Sprintf(`<rect class="heatmapCell" x="38" y="34" width="10" height="10" rx="2" fill="%s" data-count="1" />`, ramp[3]), // Mon 1 Jan in the top row
+				`<text x="8" y="39" font-size="9" dominant-baseline="central" fill="black">Lun</text>`,
+				`>Févr</text>`,
+				`>Moins</text>`,
+				`>Plus</text>`,
+			},
+		},
+		{
+			name:           "Unsupported locale",
+			queryString:    "/calendar/year?year=2024&locale=xx",
+			expectedStatus: http.StatusBadRequest,
+			expectInBody:   []string{"Unsupported locale: xx"},
+		},
+		{
+			name:           "Year that needs 54 columns",
+			queryString:    "/calendar/year?year=2028",
+			expectedStatus: http.StatusOK,
+			expectedCells:  366,
+			expectInBody:   []string{`<svg width="748px"`},
+		},
+		{
+			name:           "Five levels",
+			queryString:    "/calendar/year?year=2023&levels=5",
+			expectedStatus: http.StatusOK,
+			expectedCells:  365,
+			expectInBody:   []string{fmt.Sprintf(`fill="%s" />`, Colors.DarkGreen)},
+		},
+		{
+			name:           "Counts outside the year are ignored",
+			queryString:    "/calendar/year?year=2023&counts=2022-12-31:5",
+			expectedStatus: http.StatusOK,
+			expectedCells:  365,
+		},
+		{
+			name:           "Invalid year",
+			queryString:    "/calendar/year?year=abc",
+			expectedStatus: http.StatusBadRequest,
+			expectInBody:   []string{"Invalid year format"},
+		},
+		{
+			name:           "Invalid date",
+			queryString:    "/calendar/year?year=2024&counts=2024-13-01:2",
+			expectedStatus: http.StatusBadRequest,
+			expectInBody:   []string{"Invalid count date: 2024-13-01:2"},
+		},
+		{
+			name:           "Invalid count",
+			queryString:    "/calendar/year?year=2024&counts=2024-01-01:-2",
+			expectedStatus: http.StatusBadRequest,
+			expectInBody:   []string{"Invalid count format: 2024-01-01:-2"},
+		},
+		{
+			name:           "Levels out of range",
+			queryString:    "/calendar/year?levels=0",
+			expectedStatus: http.StatusBadRequest,
+			expectInBody:   []string{"Levels must be between 1 and 9"},
+		},
+	}
+
+	cellRegex := regexp.MustCompile(`class="heatmapCell"`)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.queryString, nil)
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+
+			body := w.Body.String()
+			if cells := len(cellRegex.FindAllString(body, -1)); cells != tc.expectedCells {
+				t.Errorf("Expected %d cells, got %d", tc.expectedCells, cells)
+			}
+			for _, str := range tc.expectInBody {
+				if !strings.Contains(body, str) {
+					t.Errorf("Expected to find %s in response body", str)
+				}
+			}
+		})
+	}
+}
+
+func TestHeatmapColor(t *testing.T) {
+	ramp := []string{"#1", "#2", "#3", "#4"}
+	testCases := []struct {
+		count, maxCount int
+		expected        string
+	}{
+		{0, 10, emptyDayColor},
+		{1, 10, "#1"},
+		{3, 10, "#2"},
+		{5, 10, "#2"},
+		{6, 10, "#3"},
+		{10, 10, "#4"},
+		{1, 1, "#4"},
+		{0, 0, emptyDayColor},
+	}
+	for _, tc := range testCases {
+		if actual := heatmapColor(tc.count, tc.maxCount, ramp); actual != tc.expected {
+			t.Errorf("heatmapColor(%d, %d) = %s; expected %s", tc.count, tc.maxCount, actual, tc.expected)
+		}
+	}
+}
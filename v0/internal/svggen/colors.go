@@ -1,5 +1,13 @@
 package svggen
 
+import (
+	"fmt"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/rasterize"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
 // ColorSet defines a set of color constants
 type ColorSet struct {
 	Green      string
@@ -10,6 +18,10 @@ type ColorSet struct {
 	Orange     string
 	Yellow     string
 	LightGreen string
+	DarkGreen  string
+	Blue       string
+	Purple     string
+	Teal       string
 }
 
 // Colors holds the application-wide color constants
@@ -22,4 +34,216 @@ var Colors = ColorSet{
 	Orange:     "orange",
 	Yellow:     "yellow",
 	LightGreen: "#99F255",
+	DarkGreen:  "#216E39",
+	Blue:       "#3B82F6",
+	Purple:     "#A855F7",
+	Teal:       "#14B8A6",
+}
+
+// colorRamp returns n colors evenly spaced along the gradient through the given hex stops.
+// Stops that are not #rrggbb hex colors cannot be interpolated, so the ramp then
+// repeats the nearest stop instead.
+func colorRamp(stops []string, n int) []string {
+	ramp := make([]string, n)
+	for i := range ramp {
+		t := 0.0
+		if n > 1 {
+			t = float64(i) / float64(n-1)
+		}
+		position := t * float64(len(stops)-1)
+		index := int(position)
+		if index >= len(stops)-1 {
+			ramp[i] = stops[len(stops)-1]
+			continue
+		}
+		from, okFrom := parseHexColor(stops[index])
+		to, okTo := parseHexColor(stops[index+1])
+		if !okFrom || !okTo {
+			ramp[i] = stops[index]
+			if position-float64(index) >= 0.5 {
+				ramp[i] = stops[index+1]
+			}
+			continue
+		}
+		frac := position - float64(index)
+		var mixed [3]int
+		for c := range mixed {
+			mixed[c] = int(float64(from[c]) + (float64(to[c])-float64(from[c]))*frac + 0.5)
+		}
+		ramp[i] = fmt.Sprintf("#%02X%02X%02X", mixed[0], mixed[1], mixed[2])
+	}
+	return ramp
+}
+
+// parseHexColor parses a #rrggbb color into its red, green and blue channels.
+func parseHexColor(color string) ([3]int, bool) {
+	var rgb [3]int
+	if len(color) != 7 || !strings.HasPrefix(color, "#") {
+		return rgb, false
+	}
+	for i := range rgb {
+		v, err := strconv.ParseUint(color[1+2*i:3+2*i], 16, 8)
+		if err != nil {
+			return rgb, false
+		}
+		rgb[i] = int(v)
+	}
+	return rgb, true
+}
+
+// ColorOverrides replace colors of the theme for a single chart.
+// Empty fields keep the color of the theme.
+type ColorOverrides struct {
+	Background string   // Behind the chart
+	Text       string   // Labels
+	Muted      string   // Secondary labels such as weekday names
+	Inactive   string   // Unfilled part of a progress chart
+	Active     string   // Filled part of a progress chart and calendar progress days
+	ActiveText string   // Labels drawn over a progress bar or a marked calendar day
+	Empty      string   // Calendar days without progress
+	Border     string   // Outline of calendar days
+	Palette    []string // Gauge sections, count shades, or the colors of segments and categories
+}
+
+// namedColor resolves a color given by name. The names of the ColorSet fields, in any
+// case, select the colors the charts are drawn with, so "green" is Colors.Green;
+// anything else must be a color the rasterizer understands.
+func namedColor(color string) (string, bool) {
+	set := reflect.ValueOf(Colors)
+	for i := range set.NumField() {
+		if strings.EqualFold(set.Type().Field(i).Name, color) {
+			return set.Field(i).String(), true
+		}
+	}
+	return color, rasterize.IsColor(color)
+}
+
+// colorParam is a query parameter holding one of the ColorOverrides.
+type colorParam struct {
+	Name  string
+	Value *string
+}
+
+// params returns the query parameter of each single color override, in a fixed order.
+func (o *ColorOverrides) params() []colorParam {
+	return []colorParam{
+		{"background", &o.Background},
+		{"text", &o.Text},
+		{"muted", &o.Muted},
+		{"inactive", &o.Inactive},
+		{"active", &o.Active},
+		{"activeText", &o.ActiveText},
+		{"empty", &o.Empty},
+		{"border", &o.Border},
+	}
+}
+
+// colorParamNames returns the names of every color query parameter.
+func colorParamNames() []string {
+	var o ColorOverrides
+	var names []string
+	for _, param := range o.params() {
+		names = append(names, param.Name)
+	}
+	return append(names, "palette")
+}
+
+// resolve returns the overrides with every color name resolved by namedColor, so a
+// name means the same color in every parameter. Returns a *ParamError if an override is not a color.
+func (o ColorOverrides) resolve() (ColorOverrides, error) {
+	for _, param := range o.params() {
+		if *param.Value == "" {
+			continue
+		}
+		color, ok := namedColor(*param.Value)
+		if !ok {
+			return ColorOverrides{}, &ParamError{fmt.Sprintf("Invalid color: %s (must be a hex, rgb() or CSS color name)", *param.Value)}
+		}
+		*param.Value = color
+	}
+	palette := make([]string, 0, len(o.Palette))
+	for _, color := range o.Palette {
+		resolved, ok := namedColor(color)
+		if !ok {
+			return ColorOverrides{}, &ParamError{fmt.Sprintf("Invalid color: %s (must be a hex, rgb() or CSS color name)", color)}
+		}
+		palette = append(palette, resolved)
+	}
+	if len(palette) > 0 {
+		o.Palette = palette
+	}
+	return o, nil
+}
+
+// apply returns t with the overrides in place. A dark variant gets the same overrides,
+// since a color picked for a chart is meant for every color scheme.
+func (o ColorOverrides) apply(t theme) theme {
+	set := func(role *string, color string) {
+		if color != "" {
+			*role = color
+		}
+	}
+	set(&t.Background, o.Background)
+	set(&t.Text, o.Text)
+	set(&t.MutedText, o.Muted)
+	set(&t.Track, o.Inactive)
+	set(&t.Fill, o.Active)
+	set(&t.Progress, o.Active)
+	set(&t.OnFill, o.ActiveText)
+	set(&t.Empty, o.Empty)
+	set(&t.Border, o.Border)
+	if len(o.Palette) > 0 {
+		t.Palette = o.Palette
+	}
+	if t.Dark != nil {
+		dark := o.apply(*t.Dark)
+		t.Dark = &dark
+	}
+	return t
+}
+
+// readColorOverrides reads the color query parameters, resolving names with namedColor
+// and recording an error for each value that is not a color.
+// Palette colors are comma separated; commas inside rgb() are kept.
+func readColorOverrides(r *paramReader) ColorOverrides {
+	var o ColorOverrides
+	for _, param := range o.params() {
+		color, ok := namedColor(r.String(param.Name, ""))
+		if color != "" && !ok {
+			r.Fail(param.Name, fmt.Sprintf("Invalid %s color: %s (must be a hex, rgb() or CSS color name)", param.Name, color))
+			continue
+		}
+		*param.Value = color
+	}
+	if r.Has("palette") {
+		for _, color := range splitColors(r.String("palette", "")) {
+			color, ok := namedColor(color)
+			if !ok {
+				r.Fail("palette", fmt.Sprintf("Invalid palette color: %s (must be a hex, rgb() or CSS color name)", color))
+				continue
+			}
+			o.Palette = append(o.Palette, color)
+		}
+	}
+	return o
+}
+
+// splitColors splits a comma separated list of colors, ignoring commas inside parentheses.
+func splitColors(list string) []string {
+	var colors []string
+	depth, start := 0, 0
+	for i, c := range list {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				colors = append(colors, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(colors, list[start:])
 }
@@ -0,0 +1,65 @@
+package svggen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestColorRamp(t *testing.T) {
+	testCases := []struct {
+		name     string
+		stops    []string
+		n        int
+		expected []string
+	}{
+		{"Two stops", []string{"#000000", "#FFFFFF"}, 3, []string{"#000000", "#808080", "#FFFFFF"}},
+		{"Three stops", []string{"#FF0000", "#00FF00", "#0000FF"}, 5, []string{"#FF0000", "#808000", "#00FF00", "#008080", "#0000FF"}},
+		{"Single color", []string{"#FF0000", "#0000FF"}, 1, []string{"#FF0000"}},
+		{"Named stops", []string{"red", "green"}, 3, []string{"red", "green", "green"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := colorRamp(tc.stops, tc.n); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("colorRamp(%v, %d) = %v; expected %v", tc.stops, tc.n, actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSplitColors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		list     string
+		expected []string
+	}{
+		{"Names", "red,green", []string{"red", "green"}},
+		{"Commas inside rgb", "rgb(1, 2, 3),#FFF,rgba(0,0,0,0.5)", []string{"rgb(1, 2, 3)", "#FFF", "rgba(0,0,0,0.5)"}},
+		{"Single color", "red", []string{"red"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := splitColors(tc.list); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("splitColors(%q) = %v; expected %v", tc.list, actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestColorOverridesResolve(t *testing.T) {
+	overrides := ColorOverrides{Active: "green", Text: "navy", Palette: []string{"Red", "teal", "#123"}}
+	resolved, err := overrides.resolve()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := ColorOverrides{Active: Colors.Green, Text: "navy", Palette: []string{Colors.Red, Colors.Teal, "#123"}}
+	if !reflect.DeepEqual(resolved, expected) {
+		t.Errorf("resolve() = %+v; expected %+v", resolved, expected)
+	}
+	if overrides.Palette[1] != "teal" {
+		t.Errorf("Expected resolve to leave the caller's palette unchanged")
+	}
+
+	if _, err := (ColorOverrides{Border: "blurple"}).resolve(); err == nil {
+		t.Errorf("Expected an error for an unknown color")
+	}
+}
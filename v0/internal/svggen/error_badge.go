@@ -0,0 +1,22 @@
+package svggen
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// renderErrorBadge writes a small badge showing message, used in place of a chart
+// that could not be rendered so the problem is visible where the image is embedded.
+// It is drawn in fixed colors, as the theme of the request may be what is wrong.
+func renderErrorBadge(w io.Writer, message string) error {
+	if utf8.RuneCountInString(message) > maxBadgeRunes {
+		message = string([]rune(message)[:maxBadgeRunes-1]) + "…"
+	}
+	return RenderBadge(w, BadgeOptions{
+		Label:      "error",
+		Message:    message,
+		Color:      "#E05D44",
+		LabelColor: "#555",
+		Theme:      DefaultTheme,
+	})
+}
@@ -0,0 +1,30 @@
+package svggen
+
+// legendEntry is a colored swatch followed by its label.
+type legendEntry struct {
+	X, Y        int
+	Name, Color string
+}
+
+// seriesColors returns the colors assigned, in order, to the series of a chart
+// such as calendar categories or bar segments.
+func seriesColors() []string {
+	return []string{Colors.Green, Colors.Blue, Colors.Orange, Colors.Purple, Colors.Red, Colors.Teal}
+}
+
+// layoutLegend lays out one swatch per name between left and right, starting at y and
+// wrapping onto a new row when a row is full. Names are colored with palette in order.
+func layoutLegend(names, palette []string, left, right, y int) []legendEntry {
+	var legend []legendEntry
+	x := left
+	for i, name := range names {
+		width := 12 + 5 + 7*len([]rune(name)) + 15 // Swatch, gap, estimated text width and spacing
+		if x > left && x+width > right {
+			x = left
+			y += 20
+		}
+		legend = append(legend, legendEntry{X: x, Y: y, Name: name, Color: palette[i%len(palette)]})
+		x += width
+	}
+	return legend
+}
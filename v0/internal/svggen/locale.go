@@ -0,0 +1,220 @@
+package svggen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultLocale is used when no locale is given.
+const DefaultLocale = "en"
+
+// locale holds the names used to label calendars in one language.
+type locale struct {
+	Months      [12]string // Standalone month names, January first
+	ShortMonths [12]string // Abbreviated month names, January first
+	Weekdays    [7]string  // Abbreviated weekday names, Sunday first
+	Less, More  string     // Labels at the ends of an intensity legend
+}
+
+// MonthName returns the name of month in this locale.
+func (l locale) MonthName(month time.Month) string {
+	return l.Months[month-1]
+}
+
+// ShortMonthName returns the abbreviated name of month in this locale.
+func (l locale) ShortMonthName(month time.Month) string {
+	return l.ShortMonths[month-1]
+}
+
+// WeekdayName returns the abbreviated name of weekday in this locale.
+func (l locale) WeekdayName(weekday time.Weekday) string {
+	return l.Weekdays[weekday]
+}
+
+// locales are bundled so calendars can be labeled without any system locale data.
+// Keys are lowercase ISO 639-1 language codes.
+var locales = map[string]locale{
+	"cs": {
+		Months:      [12]string{"Leden", "Únor", "Březen", "Duben", "Květen", "Červen", "Červenec", "Srpen", "Září", "Říjen", "Listopad", "Prosinec"},
+		ShortMonths: [12]string{"Led", "Úno", "Bře", "Dub", "Kvě", "Čvn", "Čvc", "Srp", "Zář", "Říj", "Lis", "Pro"},
+		Weekdays:    [7]string{"Ne", "Po", "Út", "St", "Čt", "Pá", "So"},
+		Less:        "Méně",
+		More:        "Více",
+	},
+	"da": {
+		Months:      [12]string{"Januar", "Februar", "Marts", "April", "Maj", "Juni", "Juli", "August", "September", "Oktober", "November", "December"},
+		ShortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "Maj", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dec"},
+		Weekdays:    [7]string{"Søn", "Man", "Tir", "Ons", "Tor", "Fre", "Lør"},
+		Less:        "Mindre",
+		More:        "Mere",
+	},
+	"de": {
+		Months:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		ShortMonths: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		Weekdays:    [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+		Less:        "Weniger",
+		More:        "Mehr",
+	},
+	"en": {
+		Months:      [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		ShortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		Weekdays:    [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		Less:        "Less",
+		More:        "More",
+	},
+	"es": {
+		Months:      [12]string{"Enero", "Febrero", "Marzo", "Abril", "Mayo", "Junio", "Julio", "Agosto", "Septiembre", "Octubre", "Noviembre", "Diciembre"},
+		ShortMonths: [12]string{"Ene", "Feb", "Mar", "Abr", "May", "Jun", "Jul", "Ago", "Sep", "Oct", "Nov", "Dic"},
+		Weekdays:    [7]string{"Dom", "Lun", "Mar", "Mié", "Jue", "Vie", "Sáb"},
+		Less:        "Menos",
+		More:        "Más",
+	},
+	"fi": {
+		Months:      [12]string{"Tammikuu", "Helmikuu", "Maaliskuu", "Huhtikuu", "Toukokuu", "Kesäkuu", "Heinäkuu", "Elokuu", "Syyskuu", "Lokakuu", "Marraskuu", "Joulukuu"},
+		ShortMonths: [12]string{"Tammi", "Helmi", "Maalis", "Huhti", "Touko", "Kesä", "Heinä", "Elo", "Syys", "Loka", "Marras", "Joulu"},
+		Weekdays:    [7]string{"Su", "Ma", "Ti", "Ke", "To", "Pe", "La"},
+		Less:        "Vähemmän",
+		More:        "Enemmän",
+	},
+	"fr": {
+		Months:      [12]string{"Janvier", "Février", "Mars", "Avril", "Mai", "Juin", "Juillet", "Août", "Septembre", "Octobre", "Novembre", "Décembre"},
+		ShortMonths: [12]string{"Janv", "Févr", "Mars", "Avr", "Mai", "Juin", "Juil", "Août", "Sept", "Oct", "Nov", "Déc"},
+		Weekdays:    [7]string{"Dim", "Lun", "Mar", "Mer", "Jeu", "Ven", "Sam"},
+		Less:        "Moins",
+		More:        "Plus",
+	},
+	"it": {
+		Months:      [12]string{"Gennaio", "Febbraio", "Marzo", "Aprile", "Maggio", "Giugno", "Luglio", "Agosto", "Settembre", "Ottobre", "Novembre", "Dicembre"},
+		ShortMonths: [12]string{"Gen", "Feb", "Mar", "Apr", "Mag", "Giu", "Lug", "Ago", "Set", "Ott", "Nov", "Dic"},
+		Weekdays:    [7]string{"Dom", "Lun", "Mar", "Mer", "Gio", "Ven", "Sab"},
+		Less:        "Meno",
+		More:        "Più",
+	},
+	"ja": {
+		Months:      [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		ShortMonths: [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		Weekdays:    [7]string{"日", "月", "火", "水", "木", "金", "土"},
+		Less:        "少ない",
+		More:        "多い",
+	},
+	"ko": {
+		Months:      [12]string{"1월", "2월", "3월", "4월", "5월", "6월", "7월", "8월", "9월", "10월", "11월", "12월"},
+		ShortMonths: [12]string{"1월", "2월", "3월", "4월", "5월", "6월", "7월", "8월", "9월", "10월", "11월", "12월"},
+		Weekdays:    [7]string{"일", "월", "화", "수", "목", "금", "토"},
+		Less:        "적음",
+		More:        "많음",
+	},
+	"nb": {
+		Months:      [12]string{"Januar", "Februar", "Mars", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Desember"},
+		ShortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Des"},
+		Weekdays:    [7]string{"Søn", "Man", "Tir", "Ons", "Tor", "Fre", "Lør"},
+		Less:        "Mindre",
+		More:        "Mer",
+	},
+	"nl": {
+		Months:      [12]string{"Januari", "Februari", "Maart", "April", "Mei", "Juni", "Juli", "Augustus", "September", "Oktober", "November", "December"},
+		ShortMonths: [12]string{"Jan", "Feb", "Mrt", "Apr", "Mei", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dec"},
+		Weekdays:    [7]string{"Zo", "Ma", "Di", "Wo", "Do", "Vr", "Za"},
+		Less:        "Minder",
+		More:        "Meer",
+	},
+	"pl": {
+		Months:      [12]string{"Styczeń", "Luty", "Marzec", "Kwiecień", "Maj", "Czerwiec", "Lipiec", "Sierpień", "Wrzesień", "Październik", "Listopad", "Grudzień"},
+		ShortMonths: [12]string{"Sty", "Lut", "Mar", "Kwi", "Maj", "Cze", "Lip", "Sie", "Wrz", "Paź", "Lis", "Gru"},
+		Weekdays:    [7]string{"Nd", "Pn", "Wt", "Śr", "Cz", "Pt", "Sb"},
+		Less:        "Mniej",
+		More:        "Więcej",
+	},
+	"pt": {
+		Months:      [12]string{"Janeiro", "Fevereiro", "Março", "Abril", "Maio", "Junho", "Julho", "Agosto", "Setembro", "Outubro", "Novembro", "Dezembro"},
+		ShortMonths: [12]string{"Jan", "Fev", "Mar", "Abr", "Mai", "Jun", "Jul", "Ago", "Set", "Out", "Nov", "Dez"},
+		Weekdays:    [7]string{"Dom", "Seg", "Ter", "Qua", "Qui", "Sex", "Sáb"},
+		Less:        "Menos",
+		More:        "Mais",
+	},
+	"ru": {
+		Months:      [12]string{"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь", "Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь"},
+		ShortMonths: [12]string{"Янв", "Фев", "Мар", "Апр", "Май", "Июн", "Июл", "Авг", "Сен", "Окт", "Ноя", "Дек"},
+		Weekdays:    [7]string{"Вс", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"},
+		Less:        "Меньше",
+		More:        "Больше",
+	},
+	"sv": {
+		Months:      [12]string{"Januari", "Februari", "Mars", "April", "Maj", "Juni", "Juli", "Augusti", "September", "Oktober", "November", "December"},
+		ShortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "Maj", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dec"},
+		Weekdays:    [7]string{"Sön", "Mån", "Tis", "Ons", "Tor", "Fre", "Lör"},
+		Less:        "Mindre",
+		More:        "Mer",
+	},
+	"tr": {
+		Months:      [12]string{"Ocak", "Şubat", "Mart", "Nisan", "Mayıs", "Haziran", "Temmuz", "Ağustos", "Eylül", "Ekim", "Kasım", "Aralık"},
+		ShortMonths: [12]string{"Oca", "Şub", "Mar", "Nis", "May", "Haz", "Tem", "Ağu", "Eyl", "Eki", "Kas", "Ara"},
+		Weekdays:    [7]string{"Paz", "Pzt", "Sal", "Çar", "Per", "Cum", "Cmt"},
+		Less:        "Az",
+		More:        "Çok",
+	},
+	"uk": {
+		Months:      [12]string{"Січень", "Лютий", "Березень", "Квітень", "Травень", "Червень", "Липень", "Серпень", "Вересень", "Жовтень", "Листопад", "Грудень"},
+		ShortMonths: [12]string{"Січ", "Лют", "Бер", "Кві", "Тра", "Чер", "Лип", "Сер", "Вер", "Жов", "Лис", "Гру"},
+		Weekdays:    [7]string{"Нд", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"},
+		Less:        "Менше",
+		More:        "Більше",
+	},
+	"zh": {
+		Months:      [12]string{"一月", "二月", "三月", "四月", "五月", "六月", "七月", "八月", "九月", "十月", "十一月", "十二月"},
+		ShortMonths: [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		Weekdays:    [7]string{"日", "一", "二", "三", "四", "五", "六"},
+		Less:        "少",
+		More:        "多",
+	},
+}
+
+// Locales returns the supported locale codes in alphabetical order.
+func Locales() []string {
+	codes := make([]string, 0, len(locales))
+	for code := range locales {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// lookupLocale returns the locale for a language tag such as "de", "de-AT" or "pt_BR".
+// Regions are not distinguished, so the language part alone selects the locale.
+// An empty tag selects DefaultLocale.
+func lookupLocale(tag string) (locale, error) {
+	if tag == "" {
+		tag = DefaultLocale
+	}
+	language, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	if l, ok := locales[strings.ToLower(language)]; ok {
+		return l, nil
+	}
+	return locale{}, &ParamError{fmt.Sprintf("Unsupported locale: %s (must be one of %s)", tag, strings.Join(Locales(), ", "))}
+}
+
+// readLocale reads a locale parameter, recording an error if it is not supported.
+func readLocale(r *paramReader, def string) string {
+	tag := r.String("locale", def)
+	if _, err := lookupLocale(tag); err != nil {
+		r.Fail("locale", err.Error())
+		return def
+	}
+	return tag
+}
+
+// readWeekStart reads the weekStart parameter of the calendars.
+func readWeekStart(r *paramReader, def time.Weekday) time.Weekday {
+	weekStarts := map[string]time.Weekday{"monday": time.Monday, "sunday": time.Sunday, "saturday": time.Saturday}
+	if !r.Has("weekStart") {
+		return def
+	}
+	return weekStarts[r.Enum("weekStart", "", "monday", "sunday", "saturday")]
+}
+
+// weekdayColumn returns the column (or row) of weekday in a week starting on weekStart.
+func weekdayColumn(weekday, weekStart time.Weekday) int {
+	return (int(weekday) - int(weekStart) + 7) % 7
+}
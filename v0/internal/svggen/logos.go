@@ -0,0 +1,58 @@
+package svggen
+
+import (
+	"embed"
+	"encoding/xml"
+	"path"
+	"sort"
+	"strings"
+)
+
+// iconFiles are the logos a badge can show, each a single color SVG with a 24x24 viewBox.
+//
+//go:embed icons/*.svg
+var iconFiles embed.FS
+
+// logoViewBox is the size of the viewBox every icon is drawn in.
+const logoViewBox = 24
+
+// logos maps each logo name to the path data of its icon.
+var logos = loadLogos()
+
+// loadLogos reads the path data of every embedded icon.
+func loadLogos() map[string][]string {
+	entries, err := iconFiles.ReadDir("icons")
+	if err != nil {
+		panic(err)
+	}
+	loaded := map[string][]string{}
+	for _, entry := range entries {
+		content, err := iconFiles.ReadFile(path.Join("icons", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var icon struct {
+			Paths []struct {
+				D string `xml:"d,attr"`
+			} `xml:"path"`
+		}
+		if err := xml.Unmarshal(content, &icon); err != nil {
+			panic(entry.Name() + ": " + err.Error())
+		}
+		name := strings.TrimSuffix(entry.Name(), ".svg")
+		for _, p := range icon.Paths {
+			loaded[name] = append(loaded[name], p.D)
+		}
+	}
+	return loaded
+}
+
+// Logos returns the names of the logos a badge can show, in alphabetical order.
+func Logos() []string {
+	names := make([]string, 0, len(logos))
+	for name := range logos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
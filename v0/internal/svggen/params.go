@@ -0,0 +1,140 @@
+package svggen
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// FieldError describes one query parameter that could not be used.
+type FieldError struct {
+	Param   string `json:"param"`
+	Value   string `json:"value"`
+	Message string `json:"message"`
+}
+
+// ValidationError reports every invalid query parameter of a request.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		messages[i] = fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// paramReader reads typed query parameters. Each method declares the type, default
+// and allowed range of one parameter. Invalid values are collected rather than
+// returned one at a time, so a request learns about all of its mistakes at once.
+// Absent and empty parameters both take the default.
+type paramReader struct {
+	params url.Values
+	errors []FieldError
+}
+
+func newParamReader(params url.Values) *paramReader {
+	return &paramReader{params: params}
+}
+
+// Has reports whether the parameter is present with a non-empty value.
+func (r *paramReader) Has(name string) bool {
+	return queryOrDefault(r.params, name, "") != ""
+}
+
+// String returns the parameter as given, or def.
+func (r *paramReader) String(name, def string) string {
+	if !r.Has(name) {
+		return def
+	}
+	return queryOrDefault(r.params, name, "")
+}
+
+// Int returns a whole number parameter between min and max inclusive, or def.
+func (r *paramReader) Int(name string, def, min, max int) int {
+	if !r.Has(name) {
+		return def
+	}
+	raw := r.String(name, "")
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		r.Fail(name, fmt.Sprintf("Invalid %s format: must be a whole number", name))
+		return def
+	}
+	if value < min || value > max {
+		r.Fail(name, fmt.Sprintf("%s must be between %d and %d", capitalize(name), min, max))
+		return def
+	}
+	return value
+}
+
+// Float returns a finite number parameter, or def.
+func (r *paramReader) Float(name string, def float64) float64 {
+	if !r.Has(name) {
+		return def
+	}
+	value, err := strconv.ParseFloat(r.String(name, ""), 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		r.Fail(name, fmt.Sprintf("Invalid %s format: must be a number", name))
+		return def
+	}
+	return value
+}
+
+// Bool returns a true or false parameter, or def. Anything strconv.ParseBool accepts is allowed.
+func (r *paramReader) Bool(name string, def bool) bool {
+	if !r.Has(name) {
+		return def
+	}
+	value, err := strconv.ParseBool(r.String(name, ""))
+	if err != nil {
+		r.Fail(name, fmt.Sprintf("Invalid %s format: must be true or false", name))
+		return def
+	}
+	return value
+}
+
+// Enum returns a parameter that must be one of options, or def.
+func (r *paramReader) Enum(name, def string, options ...string) string {
+	value := r.String(name, def)
+	for _, option := range options {
+		if value == option {
+			return value
+		}
+	}
+	r.Fail(name, fmt.Sprintf("Invalid %s: %s (must be %s)", name, value, joinOptions(options)))
+	return def
+}
+
+// Fail records that the parameter is invalid, with a message for the caller.
+func (r *paramReader) Fail(name, message string) {
+	r.errors = append(r.errors, FieldError{Param: name, Value: queryOrDefault(r.params, name, ""), Message: message})
+}
+
+// Err returns a *ValidationError listing every invalid parameter, or nil.
+func (r *paramReader) Err() error {
+	if len(r.errors) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: r.errors}
+}
+
+// capitalize upper-cases the first letter of a parameter name to start a sentence.
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// joinOptions lists options as "a, b or c".
+func joinOptions(options []string) string {
+	if len(options) < 2 {
+		return strings.Join(options, "")
+	}
+	return strings.Join(options[:len(options)-1], ", ") + " or " + options[len(options)-1]
+}
@@ -0,0 +1,58 @@
+package svggen
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// maxDecimals is the largest number of decimals shown in a percentage label.
+const maxDecimals = 4
+
+// Percentage returns how far value is between min and max, as a percentage.
+// The result is not clamped, so values outside the range give percentages outside 0-100.
+func Percentage(value, min, max float64) float64 {
+	return (value - min) / (max - min) * 100
+}
+
+// readPercentage reads the filled share of a progress chart from the query parameters.
+// Either percentage is given directly, or value is given together with an optional
+// max (default 100) and min (default 0). def is used when neither is present.
+func readPercentage(r *paramReader, def float64) float64 {
+	if !r.Has("value") {
+		return r.Float("percentage", def)
+	}
+	value := r.Float("value", 0)
+	min := r.Float("min", 0)
+	max := r.Float("max", 100)
+	if max <= min {
+		r.Fail("max", "max must be greater than min")
+		return def
+	}
+	return Percentage(value, min, max)
+}
+
+// readDecimals reads the number of decimals shown in a percentage label.
+func readDecimals(r *paramReader, def int) int {
+	return r.Int("decimals", def, 0, maxDecimals)
+}
+
+// checkDecimals returns a *ParamError if decimals cannot be used for a label.
+func checkDecimals(decimals int) error {
+	if decimals < 0 || decimals > maxDecimals {
+		return &ParamError{fmt.Sprintf("Decimals must be between 0 and %d", maxDecimals)}
+	}
+	return nil
+}
+
+// clampPercentage limits a percentage to the 0-100 range.
+func clampPercentage(percentage float64) float64 {
+	return math.Min(math.Max(percentage, 0), 100)
+}
+
+// formatPercentage formats a percentage label such as "72%" or "72.5%",
+// rounding half away from zero to the given number of decimals.
+func formatPercentage(percentage float64, decimals int) string {
+	scale := math.Pow(10, float64(decimals))
+	return strconv.FormatFloat(math.Round(percentage*scale)/scale, 'f', decimals, 64) + "%"
+}
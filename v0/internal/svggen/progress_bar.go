@@ -1,53 +1,237 @@
 package svggen
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"html/template"
+	"io"
+	"math"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
 const rectTemplateStr = `
-		<svg width="{{.Width}}px" height="{{.Height}}px" xmlns="http://www.w3.org/2000/svg">
+		<svg width="{{.Width}}px" height="{{.Height}}px" xmlns="http://www.w3.org/2000/svg" role="img" aria-label="{{.A11y.Title}}">
+			<title>{{.A11y.Title}}</title>{{with .A11y.Desc}}<desc>{{.}}</desc>{{end}}{{.Style}}
 			<rect rx="3" ry="3" x="0" y="0" width="{{.Width}}px" height="{{.Height}}px" fill="{{.ColorInactive}}" />
-			<rect rx="3" ry="3" x="0" y="0" width="{{.FillWidth}}px" height="{{.Height}}px" fill="{{.ColorActive}}" />
-			<text x="{{.TextX}}px" y="{{.TextY}}px" font-size="{{.FontSize}}px" dominant-baseline="central" text-anchor="middle" fill="{{.ColorWhite}}" font-family="Arial, Helvetica, sans-serif" font-weight="bold">{{.Percentage}}%</text>
+			<rect rx="3" ry="3" x="0" y="0" width="{{.FillWidth}}px" height="{{.Height}}px" fill="{{.ColorActive}}"{{with .Animation}}>
+				<animate attributeName="width" values="0;{{$.FillWidth}}" dur="{{.Dur}}" calcMode="spline" keyTimes="0;1" keySplines="{{.KeySplines}}" fill="freeze" />
+			</rect>{{else}} />{{end}}
+			<text x="{{.TextX}}px" y="{{.TextY}}px" font-size="{{.FontSize}}px" dominant-baseline="central" text-anchor="middle" fill="{{.ColorWhite}}" font-family="Arial, Helvetica, sans-serif" font-weight="bold">{{.Label}}</text>
 		</svg>
 		`
 
+var rectTemplate = template.Must(template.New("rect").Parse(rectTemplateStr))
+
+const segmentedRectTemplateStr = `
+		<svg width="{{.Width}}px" height="{{.TotalHeight}}px" xmlns="http://www.w3.org/2000/svg" role="img" aria-label="{{.A11y.Title}}">
+			<title>{{.A11y.Title}}</title>{{with .A11y.Desc}}<desc>{{.}}</desc>{{end}}{{.Style}}
+			<rect rx="3" ry="3" x="0" y="0" width="{{.Width}}px" height="{{.Height}}px" fill="{{.ColorInactive}}" />
+			{{- range $fill := .Fills }}
+			<rect rx="3" ry="3" x="0" y="0" width="{{.Width}}px" height="{{$.Height}}px" fill="{{.Color}}"{{with $.Animation}}>
+				<animate attributeName="width" values="0;{{$fill.Width}}" dur="{{.Dur}}" calcMode="spline" keyTimes="0;1" keySplines="{{.KeySplines}}" fill="freeze" />
+			</rect>{{else}} />{{end}}
+			{{- end }}
+			{{- range .Legend }}
+			<rect x="{{.X}}" y="{{.Y}}" width="12" height="12" rx="2" fill="{{.Color}}" />
+			<text x="{{add .X 17}}" y="{{add .Y 10}}" font-size="12" fill="{{$.ColorText}}" font-family="Arial, Helvetica, sans-serif">{{.Name}}</text>
+			{{- end }}
+		</svg>
+		`
+
+var segmentedRectTemplate = template.Must(template.New("segmentedRect").Funcs(template.FuncMap{"add": add}).Parse(segmentedRectTemplateStr))
+
+// BarOptions configures a linear progress bar.
+type BarOptions struct {
+	Width, Height int            // Size of the bar in pixels
+	Percentage    float64        // Filled portion of the bar, clamped to 0-100
+	Decimals      int            // Decimals shown in the percentage label, 0-4
+	Segments      []BarSegment   // Stacked portions drawn instead of Percentage when not empty
+	Legend        bool           // Show a legend of the segments below the bar
+	Animation     Animation      // Grow the fill from zero when the bar is shown
+	Thresholds    []Threshold    // Colors of the fill by percentage, replacing the theme's fill when not empty
+	Invert        bool           // Read the thresholds from 100%, for values where lower is better
+	Theme         string         // Name of the color theme, see Themes
+	Colors        ColorOverrides // Colors replacing those of the theme
+	Title, Desc   string         // Accessible title and description, generated from the chart when empty
+}
+
+// BarSegment is one named portion of a stacked progress bar.
+type BarSegment struct {
+	Name  string
+	Value float64 // Percentage of the bar, scaled down with the others when the total exceeds 100
+}
+
+// DefaultBarOptions returns the options used when a parameter is not provided.
+func DefaultBarOptions() BarOptions {
+	return BarOptions{Width: 200, Height: 30}
+}
+
 func HandleProgressBar(c *gin.Context) {
-	rectTemplate := template.Must(template.New("rect").Parse(rectTemplateStr))
-	width, _ := strconv.Atoi(c.DefaultQuery("width", "200"))
-	height, _ := strconv.Atoi(c.DefaultQuery("height", "30"))
-	percentage, _ := strconv.Atoi(c.DefaultQuery("percentage", "0"))
+	handleChart(c, renderProgressBar, nil)
+}
 
-	// Ensure percentage is within 0-100 range
-	if percentage < 0 {
-		percentage = 0
-	} else if percentage > 100 {
-		percentage = 100
+func renderProgressBar(w io.Writer, params url.Values) error {
+	opts := settings.Bar
+	r := newParamReader(params)
+	opts.Width = r.Int("width", opts.Width, 1, settings.MaxChartSize)
+	opts.Height = r.Int("height", opts.Height, 1, settings.MaxChartSize)
+	opts.Percentage = readPercentage(r, opts.Percentage)
+	opts.Decimals = readDecimals(r, opts.Decimals)
+	opts.Legend = r.Bool("legend", opts.Legend)
+	opts.Animation = readAnimation(r)
+	opts.Thresholds, opts.Invert = readThresholds(r)
+	opts.Theme = readTheme(r, settings.Theme)
+	opts.Colors = readColorOverrides(r)
+	opts.Title, opts.Desc = readAccessibility(r)
+
+	// Segments are a comma separated list of name:percentage
+	if r.Has("segments") {
+		for _, entry := range strings.Split(r.String("segments", ""), ",") {
+			name, valueStr, ok := strings.Cut(entry, ":")
+			if !ok || name == "" {
+				r.Fail("segments", fmt.Sprintf("Invalid segment format: %s", entry))
+				continue
+			}
+			value, err := strconv.ParseFloat(valueStr, 64)
+			if err != nil || value < 0 || math.IsInf(value, 0) {
+				r.Fail("segments", fmt.Sprintf("Invalid segment value: %s", entry))
+				continue
+			}
+			opts.Segments = append(opts.Segments, BarSegment{Name: name, Value: value})
+		}
 	}
 
-	fillWidth := (width * percentage) / 100
+	if err := r.Err(); err != nil {
+		return err
+	}
+	return RenderBar(w, opts)
+}
+
+// RenderBar writes a linear progress bar SVG to w.
+// Segments keep their own colors, so the thresholds only apply to a single fill.
+// Returns a *ParamError if Width or Height is below 1, Decimals is out of range, the theme
+// is unknown, a color, a threshold or the animation is invalid, there are more segments
+// than colors or a segment is negative.
+func RenderBar(w io.Writer, opts BarOptions) error {
+	if opts.Width < 1 || opts.Height < 1 {
+		return &ParamError{"Width and height must be at least 1"}
+	}
+	if err := checkDecimals(opts.Decimals); err != nil {
+		return err
+	}
+	if err := checkThresholds(opts.Thresholds); err != nil {
+		return err
+	}
+	animation, err := opts.Animation.smil()
+	if err != nil {
+		return err
+	}
+	theme, err := resolveTheme(opts.Theme, opts.Colors)
+	if err != nil {
+		return err
+	}
+	if len(opts.Segments) > 0 {
+		return renderSegmentedBar(w, opts, theme, animation)
+	}
+	width, height := opts.Width, opts.Height
+
+	// Ensure percentage is within 0-100 range
+	percentage := clampPercentage(opts.Percentage)
+
+	fillWidth := int(float64(width) * percentage / 100)
 
 	data := struct {
-		ColorActive, ColorInactive, ColorWhite                       string
-		Width, Height, FillWidth, TextX, TextY, FontSize, Percentage int
+		ColorActive, ColorInactive, ColorWhite, Label    string
+		Style                                            template.HTML
+		A11y                                             accessibility
+		Animation                                        *smilAnimation
+		Width, Height, FillWidth, TextX, TextY, FontSize int
 	}{
-		ColorActive:   Colors.Green,
-		ColorInactive: Colors.Grey,
-		ColorWhite:    Colors.White,
+		ColorActive:   thresholdColor(opts.Thresholds, opts.Invert, percentage, theme.Fill),
+		ColorInactive: theme.Track,
+		ColorWhite:    theme.OnFill,
+		Style:         theme.Style(),
+		A11y:          describe(opts.Title, opts.Desc, "Progress: "+percentText(percentage, opts.Decimals), ""),
+		Animation:     animation,
 		Width:         width,
 		Height:        height,
 		FillWidth:     fillWidth,
 		TextX:         width / 2,
 		TextY:         height / 2,
 		FontSize:      height / 2,
-		Percentage:    percentage,
+		Label:         formatPercentage(percentage, opts.Decimals),
 	}
 
-	c.Writer.Header().Set("Content-Type", "image/svg+xml")
-	err := rectTemplate.Execute(c.Writer, data)
-	if err != nil {
-		return
+	return rectTemplate.Execute(w, data)
+}
+
+// renderSegmentedBar writes a stacked progress bar with one color per segment.
+func renderSegmentedBar(w io.Writer, opts BarOptions, theme theme, animation *smilAnimation) error {
+	if len(opts.Segments) > len(seriesColors()) {
+		return &ParamError{fmt.Sprintf("At most %d segments are supported", len(seriesColors()))}
+	}
+	palette := theme.series()
+	total := 0.0
+	for _, segment := range opts.Segments {
+		if segment.Value < 0 {
+			return &ParamError{fmt.Sprintf("Segment %s must not be negative", segment.Name)}
+		}
+		total += segment.Value
+	}
+
+	// Normalize segments that overflow the bar so they fill it exactly
+	scale := 1.0
+	if total > 100 {
+		scale = 100 / total
+	}
+
+	type fill struct {
+		Width int
+		Color string
+	}
+	// Each segment is drawn from the start of the bar to its end, longest first,
+	// so the joins stay straight while both ends keep the rounded corners.
+	fills := make([]fill, len(opts.Segments))
+	names := make([]string, len(opts.Segments))
+	spoken := make([]string, len(opts.Segments))
+	cumulative := 0.0
+	for i, segment := range opts.Segments {
+		percentage := segment.Value * scale
+		cumulative += percentage
+		fills[len(fills)-1-i] = fill{Width: int(math.Round(float64(opts.Width) * cumulative / 100)), Color: palette[i%len(palette)]}
+		names[i] = segment.Name + " " + formatPercentage(percentage, opts.Decimals)
+		spoken[i] = segment.Name + " " + percentText(percentage, opts.Decimals)
+	}
+
+	totalHeight := opts.Height
+	var legend []legendEntry
+	if opts.Legend {
+		legend = layoutLegend(names, palette, 0, opts.Width, opts.Height+6)
+		totalHeight = legend[len(legend)-1].Y + 16
 	}
+
+	data := struct {
+		ColorInactive, ColorText   string
+		Style                      template.HTML
+		A11y                       accessibility
+		Animation                  *smilAnimation
+		Width, Height, TotalHeight int
+		Fills                      []fill
+		Legend                     []legendEntry
+	}{
+		ColorInactive: theme.Track,
+		ColorText:     theme.Text,
+		Style:         theme.Style(),
+		A11y:          describe(opts.Title, opts.Desc, "Progress: "+strings.Join(spoken, ", "), ""),
+		Animation:     animation,
+		Width:         opts.Width,
+		Height:        opts.Height,
+		TotalHeight:   totalHeight,
+		Fills:         fills,
+		Legend:        legend,
+	}
+
+	return segmentedRectTemplate.Execute(w, data)
 }
@@ -32,7 +32,7 @@ func TestHandleProgressBar(t *testing.T) {
 			queryString:    "/progress/bar?width=221&height=33&percentage=54",
 			expectedStatus: http.StatusOK,
 			expectedInBody: []string{
-				`<svg width="221px" height="33px" xmlns="http://www.w3.org/2000/svg">`,
+				`<svg width="221px" height="33px" xmlns="http://www.w3.org/2000/svg" role="img"`,
 				fmt.Sprintf("<rect rx=\"3\" ry=\"3\" x=\"0\" y=\"0\" width=\"221px\" height=\"33px\" fill=\"%s\" />", Colors.Grey),
 				fmt.Sprintf("<rect rx=\"3\" ry=\"3\" x=\"0\" y=\"0\" width=\"119px\" height=\"33px\" fill=\"%s\" />", Colors.Green),
 				fmt.Sprintf("<text x=\"110px\" y=\"16px\" font-size=\"16px\" dominant-baseline=\"central\" text-anchor=\"middle\" fill=\"%s\" font-family=\"Arial, Helvetica, sans-serif\" font-weight=\"bold\">54%%</text>", Colors.White),
@@ -51,6 +51,142 @@ func TestHandleProgressBar(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedInBody: []string{"width=\"200px\"", "height=\"30px\"", fmt.Sprintf("fill=\"%s\"", Colors.Green), "100%"},
 		},
+		{
+			name:           "Value and max with decimals",
+			queryString:    "/progress/bar?width=200&height=30&value=37&max=52&decimals=1",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{
+				fmt.Sprintf(`<rect rx="3" ry="3" x="0" y="0" width="142px" height="30px" fill="%s" />`, Colors.Green),
+				">71.2%</text>",
+			},
+		},
+		{
+			name:           "Invalid percentage",
+			queryString:    "/progress/bar?percentage=abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"Invalid percentage format"},
+		},
+		{
+			name:           "Decimals out of range",
+			queryString:    "/progress/bar?percentage=50&decimals=9",
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"Decimals must be between 0 and 4"},
+		},
+		{
+			name:           "Segments",
+			queryString:    "/progress/bar?width=200&height=20&segments=done:40,review:15,todo:45",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{
+				`<svg width="200px" height="20px" xmlns="http://www.w3.org/2000/svg" role="img"`,
+				fmt.Sprintf(`<rect rx="3" ry="3" x="0" y="0" width="200px" height="20px" fill="%s" />`, Colors.Orange),
+				fmt.Sprintf(`<rect rx="3" ry="3" x="0" y="0" width="110px" height="20px" fill="%s" />`, Colors.Blue),
+				fmt.Sprintf(`<rect rx="3" ry="3" x="0" y="0" width="80px" height="20px" fill="%s" />`, Colors.Green),
+			},
+		},
+		{
+			name:           "Segments overflowing 100 are normalized",
+			queryString:    "/progress/bar?width=200&height=20&segments=done:100,todo:100&legend=true",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{
+				`<svg width="200px" height="42px" xmlns="http://www.w3.org/2000/svg" role="img"`,
+				fmt.Sprintf(`<rect rx="3" ry="3" x="0" y="0" width="100px" height="20px" fill="%s" />`, Colors.Green),
+				`<rect x="0" y="26" width="12" height="12" rx="2" fill="#44CC11" />`,
+				`>done 50%</text>`,
+				`>todo 50%</text>`,
+			},
+		},
+		{
+			name:           "Invalid segment",
+			queryString:    "/progress/bar?segments=done",
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"Invalid segment format: done"},
+		},
+		{
+			name:           "Negative segment",
+			queryString:    "/progress/bar?segments=done:-5",
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"Invalid segment value: done:-5"},
+		},
+		{
+			name:           "Too many segments",
+			queryString:    "/progress/bar?segments=a:1,b:1,c:1,d:1,e:1,f:1,g:1",
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"At most 6 segments are supported"},
+		},
+		{
+			name:           "Animated",
+			queryString:    "/progress/bar?width=200&percentage=50&animate=true&duration=2.5&easing=linear",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{
+				`<animate attributeName="width" values="0;100"`,
+				`dur="2.5s"`,
+				`keySplines="0 0 1 1"`,
+				`fill="freeze"`,
+			},
+		},
+		{
+			name:           "Invalid animation",
+			queryString:    "/progress/bar?percentage=50&animate=true&duration=0&easing=bounce",
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"Duration must be more than 0 and at most 60 seconds", "Invalid easing: bounce"},
+		},
+		{
+			name:           "Accessible title",
+			queryString:    "/progress/bar?percentage=72.5&decimals=1",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`aria-label="Progress: 72.5 percent"`, `<title>Progress: 72.5 percent</title>`},
+		},
+		{
+			name:           "Accessible title of segments",
+			queryString:    "/progress/bar?segments=done:40,todo:60",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`<title>Progress: done 40 percent, todo 60 percent</title>`},
+		},
+		{
+			name:           "Color overrides",
+			queryString:    "/progress/bar?width=100&height=20&percentage=50&active=%23FF0000&inactive=rgb(0,%200,%200)&activeText=navy",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{
+				`width="100px" height="20px" fill="rgb(0, 0, 0)" />`,
+				`width="50px" height="20px" fill="#FF0000" />`,
+				`text-anchor="middle" fill="navy"`,
+			},
+		},
+		{
+			name:           "Palette colors the segments",
+			queryString:    "/progress/bar?width=100&height=20&segments=a:20,b:20,c:20&palette=red,rgb(0,0,255)",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{
+				`width="60px" height="20px" fill="red" />`,
+				`width="40px" height="20px" fill="rgb(0,0,255)" />`,
+				`width="20px" height="20px" fill="red" />`,
+			},
+		},
+		{
+			name:           "Chart color names",
+			queryString:    "/progress/bar?width=100&height=20&segments=a:50,b:50&palette=green,Blue&inactive=grey",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{
+				fmt.Sprintf(`width="100px" height="20px" fill="%s" />`, Colors.Grey),
+				fmt.Sprintf(`width="100px" height="20px" fill="%s" />`, Colors.Blue),
+				fmt.Sprintf(`width="50px" height="20px" fill="%s" />`, Colors.Green),
+			},
+		},
+		{
+			name:           "Invalid color",
+			queryString:    "/progress/bar?active=%23GG0000&palette=red,blurple",
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{
+				"Invalid active color: #GG0000 (must be a hex, rgb() or CSS color name)",
+				"Invalid palette color: blurple",
+			},
+		},
+		{
+			name:           "Markup is not a color",
+			queryString:    "/progress/bar?background=red%22%20onload=%22alert(1)",
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"Invalid background color"},
+		},
 	}
 
 	for _, tc := range testCases {
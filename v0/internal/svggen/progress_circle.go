@@ -1,50 +1,125 @@
 package svggen
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"html/template"
-	"strconv"
+	"io"
+	"net/url"
 )
 
 const circleTemplateStr = `
 
-	<svg height="{{.Size}}px" width="{{.Size}}px" viewBox="0 0 {{.Size}} {{.Size}}" xmlns="http://www.w3.org/2000/svg">
+	<svg height="{{.Size}}px" width="{{.Size}}px" viewBox="0 0 {{.Size}} {{.Size}}" xmlns="http://www.w3.org/2000/svg" role="img" aria-label="{{.A11y.Title}}">
+		<title>{{.A11y.Title}}</title>{{with .A11y.Desc}}<desc>{{.}}</desc>{{end}}{{.Style}}
 		<circle cx="{{.Center}}" cy="{{.Center}}" r="{{.Radius}}" stroke="{{.ColorInactive}}" stroke-width="{{.StrokeWidth}}" fill="{{.ColorWhite}}" />
-		<circle cx="{{.Center}}" cy="{{.Center}}" r="{{.Radius}}" stroke="{{.ColorActive}}" stroke-width="{{.StrokeWidth}}" fill="none" stroke-dasharray="{{.StrokeDasharrayFilled}}, {{.StrokeDasharrayUnfilled}}" stroke-dashoffset="0" transform="rotate(-90, {{.Center}}, {{.Center}})" />
-		<text x="{{.Center}}" y="{{.Center}}" font-size="{{.FontSize}}px" dominant-baseline="central" text-anchor="middle" fill="{{.ColorBlack}}" font-family="Arial, Helvetica, sans-serif" font-weight="bold">{{.Percentage}}%</text>
+		<circle cx="{{.Center}}" cy="{{.Center}}" r="{{.Radius}}" stroke="{{.ColorActive}}" stroke-width="{{.StrokeWidth}}" fill="none" stroke-dasharray="{{.StrokeDasharrayFilled}}, {{.StrokeDasharrayUnfilled}}" stroke-dashoffset="0" transform="rotate(-90, {{.Center}}, {{.Center}})"{{with .Animation}}>
+			<animate attributeName="stroke-dasharray" values="0, {{$.Circumference}};{{$.StrokeDasharrayFilled}}, {{$.StrokeDasharrayUnfilled}}" dur="{{.Dur}}" calcMode="spline" keyTimes="0;1" keySplines="{{.KeySplines}}" fill="freeze" />
+		</circle>{{else}} />{{end}}
+		<text x="{{.Center}}" y="{{.Center}}" font-size="{{.FontSize}}px" dominant-baseline="central" text-anchor="middle" fill="{{.ColorBlack}}" font-family="Arial, Helvetica, sans-serif" font-weight="bold">{{.Label}}</text>
 	</svg>
 	`
 
+var circleTemplate = template.Must(template.New("circle").Parse(circleTemplateStr))
+
+// CircleOptions configures a circular progress bar.
+type CircleOptions struct {
+	Size        int            // Width and height of the chart in pixels
+	StrokeWidth int            // Width of the ring in pixels; 0 for the default of 15
+	Percentage  float64        // Filled portion of the ring, clamped to 0-100
+	Decimals    int            // Decimals shown in the percentage label, 0-4
+	Animation   Animation      // Sweep the ring from zero when the chart is shown
+	Thresholds  []Threshold    // Colors of the ring by percentage, replacing the theme's fill when not empty
+	Invert      bool           // Read the thresholds from 100%, for values where lower is better
+	Theme       string         // Name of the color theme, see Themes
+	Colors      ColorOverrides // Colors replacing those of the theme
+	Title, Desc string         // Accessible title and description, generated from the chart when empty
+}
+
+// defaultStrokeWidth is the width of the ring when the options do not set one.
+const defaultStrokeWidth = 15
+
+// DefaultCircleOptions returns the options used when a parameter is not provided.
+func DefaultCircleOptions() CircleOptions {
+	return CircleOptions{Size: 100, StrokeWidth: defaultStrokeWidth}
+}
+
 func HandleProgressCircle(c *gin.Context) {
-	circleTemplate := template.Must(template.New("circle").Parse(circleTemplateStr))
+	handleChart(c, renderProgressCircle, nil)
+}
 
-	size, _ := strconv.Atoi(c.DefaultQuery("size", "100"))
-	percentage, _ := strconv.Atoi(c.DefaultQuery("percentage", "0"))
+func renderProgressCircle(w io.Writer, params url.Values) error {
+	opts := settings.Circle
+	r := newParamReader(params)
+	opts.Size = r.Int("size", opts.Size, 1, settings.MaxChartSize)
+	opts.Percentage = readPercentage(r, opts.Percentage)
+	opts.Decimals = readDecimals(r, opts.Decimals)
+	opts.Animation = readAnimation(r)
+	opts.Thresholds, opts.Invert = readThresholds(r)
+	opts.Theme = readTheme(r, settings.Theme)
+	opts.Colors = readColorOverrides(r)
+	opts.Title, opts.Desc = readAccessibility(r)
+	if err := r.Err(); err != nil {
+		return err
+	}
+	return RenderCircle(w, opts)
+}
 
-	if percentage < 0 {
-		percentage = 0
-	} else if percentage > 100 {
-		percentage = 100
+// RenderCircle writes a circular progress bar SVG to w.
+// Returns a *ParamError if Decimals is out of range, StrokeWidth is negative, Size leaves no room
+// for the ring, the theme is unknown or a color, a threshold or the animation is invalid.
+func RenderCircle(w io.Writer, opts CircleOptions) error {
+	if err := checkDecimals(opts.Decimals); err != nil {
+		return err
+	}
+	if err := checkThresholds(opts.Thresholds); err != nil {
+		return err
 	}
+	if opts.StrokeWidth < 0 {
+		return &ParamError{"Stroke width must not be negative"}
+	}
+	if opts.StrokeWidth == 0 {
+		opts.StrokeWidth = defaultStrokeWidth
+	}
+	if opts.Size <= 2*opts.StrokeWidth {
+		return &ParamError{fmt.Sprintf("Size must be greater than twice the stroke width (%d)", opts.StrokeWidth)}
+	}
+	animation, err := opts.Animation.smil()
+	if err != nil {
+		return err
+	}
+	theme, err := resolveTheme(opts.Theme, opts.Colors)
+	if err != nil {
+		return err
+	}
+	size, percentage := opts.Size, clampPercentage(opts.Percentage)
 
-	strokeWidth := 15
+	strokeWidth := opts.StrokeWidth
 	radius := float64(size)/2 - float64(strokeWidth)
 	circumference := 2 * 3.14 * radius
-	strokeDasharrayFilled := circumference * float64(percentage) / 100
+	strokeDasharrayFilled := circumference * percentage / 100
 	strokeDasharrayUnfilled := circumference - strokeDasharrayFilled
 
 	data := struct {
-		ColorActive, ColorInactive, ColorWhite, ColorBlack                       string
-		Size, StrokeWidth, Percentage                                            int
+		ColorActive, ColorInactive, ColorWhite, ColorBlack, Label                string
+		Style                                                                    template.HTML
+		A11y                                                                     accessibility
+		Animation                                                                *smilAnimation
+		Circumference                                                            float64
+		Size, StrokeWidth                                                        int
 		Radius, StrokeDasharrayFilled, StrokeDasharrayUnfilled, FontSize, Center float64
 	}{
-		ColorActive:             Colors.Green,
-		ColorInactive:           Colors.Grey,
-		ColorWhite:              Colors.White,
-		ColorBlack:              Colors.Black,
+		ColorActive:             thresholdColor(opts.Thresholds, opts.Invert, percentage, theme.Fill),
+		ColorInactive:           theme.Track,
+		ColorWhite:              theme.Background,
+		ColorBlack:              theme.Text,
+		Style:                   theme.Style(),
+		Animation:               animation,
+		Circumference:           circumference,
+		A11y:                    describe(opts.Title, opts.Desc, "Progress: "+percentText(percentage, opts.Decimals), ""),
 		Size:                    size,
 		StrokeWidth:             strokeWidth,
-		Percentage:              percentage,
+		Label:                   formatPercentage(percentage, opts.Decimals),
 		Radius:                  radius,
 		StrokeDasharrayFilled:   strokeDasharrayFilled,
 		StrokeDasharrayUnfilled: strokeDasharrayUnfilled,
@@ -52,9 +127,5 @@ func HandleProgressCircle(c *gin.Context) {
 		Center:                  float64(size) / 2,
 	}
 
-	c.Writer.Header().Set("Content-Type", "image/svg+xml")
-	err := circleTemplate.Execute(c.Writer, data)
-	if err != nil {
-		return
-	}
+	return circleTemplate.Execute(w, data)
 }
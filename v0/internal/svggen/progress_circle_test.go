@@ -16,10 +16,11 @@ func TestHandleProgressCircle(t *testing.T) {
 	router.GET("/progress/circle", HandleProgressCircle)
 
 	testCases := []struct {
-		name           string
-		queryString    string
-		expectedStatus int
-		expectedInBody []string
+		name             string
+		queryString      string
+		expectedStatus   int
+		expectedInBody   []string
+		unexpectedInBody []string
 	}{
 		{
 			name:           "Normal case",
@@ -32,7 +33,7 @@ func TestHandleProgressCircle(t *testing.T) {
 			queryString:    "/progress/circle?size=103&percentage=58",
 			expectedStatus: http.StatusOK,
 			expectedInBody: []string{
-				`<svg height="103px" width="103px" viewBox="0 0 103 103" xmlns="http://www.w3.org/2000/svg">`,
+				`<svg height="103px" width="103px" viewBox="0 0 103 103" xmlns="http://www.w3.org/2000/svg" role="img"`,
 				fmt.Sprintf("<circle cx=\"51.5\" cy=\"51.5\" r=\"36.5\" stroke=\"%s\" stroke-width=\"15\" fill=\"%s\" />", Colors.Grey, Colors.White),
 				fmt.Sprintf("<circle cx=\"51.5\" cy=\"51.5\" r=\"36.5\" stroke=\"%s\" stroke-width=\"15\" fill=\"none\" stroke-dasharray=\"132.9476, 96.2724\" stroke-dashoffset=\"0\" transform=\"rotate(-90, 51.5, 51.5)\" />", Colors.Green),
 				fmt.Sprintf("text x=\"51.5\" y=\"51.5\" font-size=\"20.6px\" dominant-baseline=\"central\" text-anchor=\"middle\" fill=\"%s\" font-family=\"Arial, Helvetica, sans-serif\" font-weight=\"bold\">58%%</text>", Colors.Black),
@@ -51,6 +52,53 @@ func TestHandleProgressCircle(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedInBody: []string{"stroke-dasharray=\"229.22, 0\"", "100%"},
 		},
+		{
+			name:           "Fractional percentage",
+			queryString:    "/progress/circle?size=103&percentage=72.5&decimals=1",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{"stroke-dasharray=\"166.1845, 63.0354", ">72.5%</text>"},
+		},
+		{
+			name:           "Value between min and max",
+			queryString:    "/progress/circle?size=103&value=15&min=10&max=20",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{">50%</text>"},
+		},
+		{
+			name:           "Max not above min",
+			queryString:    "/progress/circle?value=3&min=5&max=1",
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"max must be greater than min"},
+		},
+		{
+			name:           "Animated",
+			queryString:    "/progress/circle?size=103&percentage=58&animate=true&duration=1.5",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{
+				`stroke-dasharray="132.9476, 96.2724" stroke-dashoffset="0" transform="rotate(-90, 51.5, 51.5)">`,
+				`<animate attributeName="stroke-dasharray" values="0, 229.22;132.9476, 96.2724" dur="1.5s" calcMode="spline" keyTimes="0;1" keySplines="0 0 0.58 1" fill="freeze" />`,
+				`</circle>`,
+			},
+		},
+		{
+			name:             "Animation off",
+			queryString:      "/progress/circle?size=103&percentage=58&animate=false&duration=1.5",
+			expectedStatus:   http.StatusOK,
+			expectedInBody:   []string{`stroke-dasharray="132.9476, 96.2724" stroke-dashoffset="0" transform="rotate(-90, 51.5, 51.5)" />`},
+			unexpectedInBody: []string{"<animate"},
+		},
+		{
+			name:           "Size too small for the ring",
+			queryString:    "/progress/circle?percentage=50&size=30",
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"Size must be greater than twice the stroke width (15)"},
+		},
+		{
+			name:           "Smallest size with a ring",
+			queryString:    "/progress/circle?percentage=50&size=31",
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`r="0.5"`},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -69,6 +117,11 @@ func TestHandleProgressCircle(t *testing.T) {
 					t.Errorf("Expected to find %s in response body", str)
 				}
 			}
+			for _, str := range tc.unexpectedInBody {
+				if strings.Contains(body, str) {
+					t.Errorf("Expected not to find %s in response body", str)
+				}
+			}
 		})
 	}
 }
@@ -4,22 +4,30 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"html/template"
+	"io"
 	"math"
-	"strconv"
+	"net/url"
 )
 
 const gaugeChartTemplateStr = `
-<svg height="{{.Center}}px" width="{{.Size}}px" viewBox="0 0 {{.Size}} {{.Center}}" xmlns="http://www.w3.org/2000/svg">
+<svg height="{{.Center}}px" width="{{.Size}}px" viewBox="0 0 {{.Size}} {{.Center}}" xmlns="http://www.w3.org/2000/svg" role="img" aria-label="{{.A11y.Title}}">
+	<title>{{.A11y.Title}}</title>{{with .A11y.Desc}}<desc>{{.}}</desc>{{end}}{{.Style}}
 	{{ range .PieSections }}
 		<path d="{{.Path}}" fill="{{.FillColor}}" opacity="{{.Opacity}}"/>
 	{{ end }}
     <path d="M{{.Center}},{{.Center}} L{{mult .Center 0.5}},{{.Center}} A{{mult .Center 0.5}},{{mult .Center 0.5}} 0 1,1 {{mult .Center 1.5}},{{.Center}} Z" fill="{{.ColorWhite}}"/>
-    <polygon points="{{.Needle.X1}},{{.Needle.Y1}} {{.Needle.X2}},{{.Needle.Y2}} {{.Needle.X3}},{{.Needle.Y3}}" fill="{{.ColorBlack}}" />
+    <polygon points="{{.Needle.X1}},{{.Needle.Y1}} {{.Needle.X2}},{{.Needle.Y2}} {{.Needle.X3}},{{.Needle.Y3}}" fill="{{.ColorBlack}}"{{with .Animation}}>
+        <animateTransform attributeName="transform" type="rotate" values="{{$.NeedleStartAngle}} {{$.Center}} {{$.Center}};0 {{$.Center}} {{$.Center}}" dur="{{.Dur}}" calcMode="spline" keyTimes="0;1" keySplines="{{.KeySplines}}" fill="freeze" />
+    </polygon>{{else}} />{{end}}
     <path d="M{{.Center}},{{.Center}} L{{mult .Center 0.8}},{{.Center}} A{{mult .Center 0.2}},{{mult .Center 0.2}} 0 1,1 {{mult .Center 1.2}},{{.Center}} Z" fill="{{.ColorBlack}}"/>
     <path d="M{{.Center}},{{.Center}} L{{mult .Center 0.9}},{{.Center}} A{{mult .Center 0.1}},{{mult .Center 0.1}} 0 1,1 {{mult .Center 1.1}},{{.Center}} Z" fill="{{.ColorGrey}}"/>
 </svg>
 `
 
+var gaugeChartTemplate = template.Must(template.New("gaugeChart").Funcs(template.FuncMap{
+	"mult": multFloat64,
+}).Parse(gaugeChartTemplateStr))
+
 type Needle struct {
 	X1, Y1 float64 // First point of the triangle
 	X2, Y2 float64 // Second point of the triangle
@@ -36,21 +44,64 @@ func radians(degrees float64) float64 {
 	return degrees * (math.Pi / 180)
 }
 
-const angle = 36.0 // Angle for dividing the circle into sections
+// GaugeOptions configures a semi-circular progress gauge.
+type GaugeOptions struct {
+	Width       int            // Width of the gauge in pixels, the height is half of it
+	Percentage  float64        // Needle position, clamped to 0-100
+	Animation   Animation      // Turn the needle from zero when the gauge is shown
+	Thresholds  []Threshold    // Sections of the gauge and their colors, the five default sections when empty
+	Invert      bool           // Read the thresholds from 100%, for values where lower is better
+	Theme       string         // Name of the color theme, see Themes
+	Colors      ColorOverrides // Colors replacing those of the theme
+	Title, Desc string         // Accessible title and description, generated from the chart when empty
+}
+
+// DefaultGaugeOptions returns the options used when a parameter is not provided.
+func DefaultGaugeOptions() GaugeOptions {
+	return GaugeOptions{Width: 100}
+}
 
 func HandleProgressGauge(c *gin.Context) {
-	funcMap := template.FuncMap{
-		"mult": multFloat64,
-	}
-	gaugeChartTemplate := template.Must(template.New("gaugeChart").Funcs(funcMap).Parse(gaugeChartTemplateStr))
+	handleChart(c, renderProgressGauge, nil)
+}
 
+func renderProgressGauge(w io.Writer, params url.Values) error {
 	// Retrieve parameters or default
-	width, _ := strconv.Atoi(c.DefaultQuery("width", "100"))
-	percentage, _ := strconv.Atoi(c.DefaultQuery("percentage", "0"))
-	if width <= 0 {
-		width = 100
+	opts := settings.Gauge
+	r := newParamReader(params)
+	opts.Width = r.Int("width", opts.Width, 1, settings.MaxChartSize)
+	opts.Percentage = readPercentage(r, opts.Percentage)
+	opts.Animation = readAnimation(r)
+	opts.Thresholds, opts.Invert = readThresholds(r)
+	opts.Theme = readTheme(r, settings.Theme)
+	opts.Colors = readColorOverrides(r)
+	opts.Title, opts.Desc = readAccessibility(r)
+	if err := r.Err(); err != nil {
+		return err
+	}
+	return RenderGauge(w, opts)
+}
+
+// RenderGauge writes a progress gauge SVG to w.
+// Each threshold is drawn as a section, and the section the needle points at stands out.
+// Returns a *ParamError if Width is below 1, the theme is unknown or a color, a threshold or the animation is invalid.
+func RenderGauge(w io.Writer, opts GaugeOptions) error {
+	if opts.Width < 1 {
+		return &ParamError{"Width must be at least 1"}
 	}
-	percentage = clamp(percentage, 0, 100)
+	if err := checkThresholds(opts.Thresholds); err != nil {
+		return err
+	}
+	animation, err := opts.Animation.smil()
+	if err != nil {
+		return err
+	}
+	theme, err := resolveTheme(opts.Theme, opts.Colors)
+	if err != nil {
+		return err
+	}
+
+	width, percentage := opts.Width, clampPercentage(opts.Percentage)
 	effectiveWidth := int(float64(width) * 0.90) // Shrinking the effective width by 20% allows extension of the active section
 
 	// Calculate center and needle position based on percentage
@@ -58,48 +109,57 @@ func HandleProgressGauge(c *gin.Context) {
 	effectiveCenter := float64(effectiveWidth) / 2
 	needle := calculateNeedlePosition(center, percentage)
 
-	// divide by the number of sections
-	interval := 100 / 5
-
-	// Now check which interval the percentage belongs to
-	// activeIndex is a 1 based index, [1-5]
-	// check if percentage is 0
-	activeIndex := 0
-	if percentage > 0 {
-		activeIndex = int((percentage+interval-1)/interval) - 1
+	// Without thresholds the gauge is split into five equal sections from red to green
+	thresholds := opts.Thresholds
+	if len(thresholds) == 0 {
+		pieColors := []string{Colors.Red, Colors.Orange, Colors.Yellow, Colors.LightGreen, Colors.Green}
+		if len(theme.Palette) > 0 {
+			pieColors = colorRamp(theme.Palette, len(pieColors))
+		}
+		for i, color := range pieColors {
+			thresholds = append(thresholds, Threshold{From: float64(i) * 100 / float64(len(pieColors)), Color: color})
+		}
 	}
+	bands := thresholdBands(thresholds, opts.Invert)
+	activeIndex := activeBand(bands, percentage)
 
-	pieColors := []string{Colors.Red, Colors.Orange, Colors.Yellow, Colors.LightGreen, Colors.Green}
-	pieSections := make([]PieSection, 5) // create slice with 5 sections
-	for i := 0; i < len(pieSections); i++ {
+	pieSections := make([]PieSection, len(bands))
+	for i, b := range bands {
+		startAngle, endAngle := 180+b.From*180/100, 180+b.To*180/100
 		if i == activeIndex {
-			pieSections[i] = PieSection{pieColors[i], createPiePath(center, effectiveCenter, 180+float64(i)*angle, 180+float64(i+1)*angle, true), "1"}
+			pieSections[i] = PieSection{b.Color, createPiePath(center, effectiveCenter, startAngle, endAngle, true), "1"}
 		} else {
-			pieSections[i] = PieSection{pieColors[i], createPiePath(center, effectiveCenter, 180+float64(i)*angle, 180+float64(i+1)*angle, false), "0.5"}
+			pieSections[i] = PieSection{b.Color, createPiePath(center, effectiveCenter, startAngle, endAngle, false), "0.5"}
 		}
 	}
 
 	data := struct {
 		ColorWhite, ColorBlack, ColorGrey string
-		Percentage                        int
-		Size, Center, NeedleX, NeedleY    float64
+		Style                             template.HTML
+		A11y                              accessibility
+		Animation                         *smilAnimation
+		NeedleStartAngle                  float64
+		Percentage, Size, Center          float64
+		NeedleX, NeedleY                  float64
 		Needle                            Needle
 		PieSections                       []PieSection
 	}{
-		ColorWhite:  Colors.White,
-		ColorBlack:  Colors.Black,
-		ColorGrey:   Colors.Grey,
-		Size:        float64(width),
-		Percentage:  percentage,
-		Center:      center,
-		Needle:      needle,
-		PieSections: pieSections,
+		ColorWhite: theme.Background,
+		ColorBlack: theme.Text,
+		ColorGrey:  theme.Track,
+		Style:      theme.Style(),
+		Animation:  animation,
+		// The needle is drawn at its final position, so it starts turned back to 0%
+		NeedleStartAngle: -percentage * 180 / 100,
+		A11y:             describe(opts.Title, opts.Desc, "Progress: "+percentText(percentage, 0), ""),
+		Size:             float64(width),
+		Percentage:       percentage,
+		Center:           center,
+		Needle:           needle,
+		PieSections:      pieSections,
 	}
 
-	c.Writer.Header().Set("Content-Type", "image/svg+xml")
-	if err := gaugeChartTemplate.Execute(c.Writer, data); err != nil {
-		return
-	}
+	return gaugeChartTemplate.Execute(w, data)
 }
 
 func createPiePath(center, radius, startAngle, endAngle float64, isActive bool) string {
@@ -117,9 +177,9 @@ func createPiePath(center, radius, startAngle, endAngle float64, isActive bool)
 	return path
 }
 
-func calculateNeedlePosition(center float64, percentage int) Needle {
+func calculateNeedlePosition(center float64, percentage float64) Needle {
 	// Convert percentage to angle in radians
-	angle := float64(percentage)/100*math.Pi + math.Pi
+	angle := percentage/100*math.Pi + math.Pi
 
 	// Calculate needle end point coordinates
 	needleLength := center * 0.45
@@ -37,11 +37,10 @@ func TestHandleProgressGauge(t *testing.T) {
 		percentageParam string
 		expectedSVG     string
 	}{
-		{"100", "50", "<svg height=\"50px\" width=\"100px\" viewBox=\"0 0 100 50\" xmlns=\"http://www.w3.org/2000/svg\"><path d=\"M 5.000000,50.000000 A 45.000000,45.000000 0 0 1 13.594235,23.549664 L 50.000000,50.000000 L 5.000000,50.000000 Z\" fill=\"red\" opacity=\"0.5\"/><path d=\"M 13.594235,23.549664 A 45.000000,45.000000 0 0 1 36.094235,7.202457 L 50.000000,50.000000 L 13.594235,23.549664 Z\" fill=\"orange\" opacity=\"0.5\"/><path d=\"M 34.703659,2.922702 A 49.500000,49.500000 0 0 1 65.296341,2.922702 L 50.000000,50.000000 L 34.703659,2.922702 Z\" fill=\"yellow\" opacity=\"1\"/><path d=\"M 63.905765,7.202457 A 45.000000,45.000000 0 0 1 86.405765,23.549664 L 50.000000,50.000000 L 63.905765,7.202457 Z\" fill=\"#99F255\" opacity=\"0.5\"/><path d=\"M 86.405765,23.549664 A 45.000000,45.000000 0 0 1 95.000000,50.000000 L 50.000000,50.000000 L 86.405765,23.549664 Z\" fill=\"#44CC11\" opacity=\"0.5\"/><path d=\"M50,50 L25,50 A25,25 0 1,1 75,50 Z\" fill=\"white\"/><polygon points=\"54.852857095672256,45.61110924292003 45.14714290432774,45.61110924292004 49.999999999999986,5\" fill=\"black\" /><path d=\"M50,50 L40,50 A10,10 0 1,1 60,50 Z\" fill=\"black\"/><path d=\"M50,50 L45,50 A5,5 0 1,1 55.00000000000001,50 Z\" fill=\"#7A7A7A\"/></svg>"},
-		{"", "75", "<svg height=\"50px\" width=\"100px\" viewBox=\"0 0 100 50\" xmlns=\"http://www.w3.org/2000/svg\"><path d=\"M 5.000000,50.000000 A 45.000000,45.000000 0 0 1 13.594235,23.549664 L 50.000000,50.000000 L 5.000000,50.000000 Z\" fill=\"red\" opacity=\"0.5\"/><path d=\"M 13.594235,23.549664 A 45.000000,45.000000 0 0 1 36.094235,7.202457 L 50.000000,50.000000 L 13.594235,23.549664 Z\" fill=\"orange\" opacity=\"0.5\"/><path d=\"M 36.094235,7.202457 A 45.000000,45.000000 0 0 1 63.905765,7.202457 L 50.000000,50.000000 L 36.094235,7.202457 Z\" fill=\"yellow\" opacity=\"0.5\"/><path d=\"M 65.296341,2.922702 A 49.500000,49.500000 0 0 1 90.046341,20.904630 L 50.000000,50.000000 L 65.296341,2.922702 Z\" fill=\"#99F255\" opacity=\"1\"/><path d=\"M 86.405765,23.549664 A 45.000000,45.000000 0 0 1 95.000000,50.000000 L 50.000000,50.000000 L 86.405765,23.549664 Z\" fill=\"#44CC11\" opacity=\"0.5\"/><path d=\"M50,50 L25,50 A25,25 0 1,1 75,50 Z\" fill=\"white\"/><polygon points=\"56.53490257669732,50.328073744260905 49.6719262557391,43.46509742330269 81.81980515339464,18.180194846605357\" fill=\"black\" /><path d=\"M50,50 L40,50 A10,10 0 1,1 60,50 Z\" fill=\"black\"/><path d=\"M50,50 L45,50 A5,5 0 1,1 55.00000000000001,50 Z\" fill=\"#7A7A7A\"/></svg>"},                        // Defaults to width=100
-		{"200", "", "<svg height=\"100px\" width=\"200px\" viewBox=\"0 0 200 100\" xmlns=\"http://www.w3.org/2000/svg\"><path d=\"M 1.000000,100.000000 A 99.000000,99.000000 0 0 1 19.907318,41.809260 L 100.000000,100.000000 L 1.000000,100.000000 Z\" fill=\"red\" opacity=\"1\"/><path d=\"M 27.188471,47.099327 A 90.000000,90.000000 0 0 1 72.188471,14.404914 L 100.000000,100.000000 L 27.188471,47.099327 Z\" fill=\"orange\" opacity=\"0.5\"/><path d=\"M 72.188471,14.404914 A 90.000000,90.000000 0 0 1 127.811529,14.404914 L 100.000000,100.000000 L 72.188471,14.404914 Z\" fill=\"yellow\" opacity=\"0.5\"/><path d=\"M 127.811529,14.404914 A 90.000000,90.000000 0 0 1 172.811529,47.099327 L 100.000000,100.000000 L 127.811529,14.404914 Z\" fill=\"#99F255\" opacity=\"0.5\"/><path d=\"M 172.811529,47.099327 A 90.000000,90.000000 0 0 1 190.000000,100.000000 L 100.000000,100.000000 L 172.811529,47.099327 Z\" fill=\"#44CC11\" opacity=\"0.5\"/><path d=\"M100,100 L50,100 A50,50 0 1,1 150,100 Z\" fill=\"white\"/><polygon points=\"91.22221848584006,90.29428580865546 91.22221848584006,109.70571419134453 10,100\" fill=\"black\" /><path d=\"M100,100 L80,100 A20,20 0 1,1 120,100 Z\" fill=\"black\"/><path d=\"M100,100 L90,100 A10,10 0 1,1 110.00000000000001,100 Z\" fill=\"#7A7A7A\"/></svg>"}, // Defaults to percentage=0
-		{"-10", "30", "<svg height=\"50px\" width=\"100px\" viewBox=\"0 0 100 50\" xmlns=\"http://www.w3.org/2000/svg\"><path d=\"M 5.000000,50.000000 A 45.000000,45.000000 0 0 1 13.594235,23.549664 L 50.000000,50.000000 L 5.000000,50.000000 Z\" fill=\"red\" opacity=\"0.5\"/><path d=\"M 9.953659,20.904630 A 49.500000,49.500000 0 0 1 34.703659,2.922702 L 50.000000,50.000000 L 9.953659,20.904630 Z\" fill=\"orange\" opacity=\"1\"/><path d=\"M 36.094235,7.202457 A 45.000000,45.000000 0 0 1 63.905765,7.202457 L 50.000000,50.000000 L 36.094235,7.202457 Z\" fill=\"yellow\" opacity=\"0.5\"/><path d=\"M 63.905765,7.202457 A 45.000000,45.000000 0 0 1 86.405765,23.549664 L 50.000000,50.000000 L 63.905765,7.202457 Z\" fill=\"#99F255\" opacity=\"0.5\"/><path d=\"M 86.405765,23.549664 A 45.000000,45.000000 0 0 1 95.000000,50.000000 L 50.000000,50.000000 L 86.405765,23.549664 Z\" fill=\"#44CC11\" opacity=\"0.5\"/><path d=\"M50,50 L25,50 A25,25 0 1,1 75,50 Z\" fill=\"white\"/><polygon points=\"51.346318600737554,43.59687495874813 43.49423087739373,49.30175062338621 23.5496636468387,13.594235253127373\" fill=\"black\" /><path d=\"M50,50 L40,50 A10,10 0 1,1 60,50 Z\" fill=\"black\"/><path d=\"M50,50 L45,50 A5,5 0 1,1 55.00000000000001,50 Z\" fill=\"#7A7A7A\"/></svg>"},                     // Invalid width, defaults to 100
-		{"100", "101", "<svg height=\"50px\" width=\"100px\" viewBox=\"0 0 100 50\" xmlns=\"http://www.w3.org/2000/svg\"><path d=\"M 5.000000,50.000000 A 45.000000,45.000000 0 0 1 13.594235,23.549664 L 50.000000,50.000000 L 5.000000,50.000000 Z\" fill=\"red\" opacity=\"0.5\"/><path d=\"M 13.594235,23.549664 A 45.000000,45.000000 0 0 1 36.094235,7.202457 L 50.000000,50.000000 L 13.594235,23.549664 Z\" fill=\"orange\" opacity=\"0.5\"/><path d=\"M 36.094235,7.202457 A 45.000000,45.000000 0 0 1 63.905765,7.202457 L 50.000000,50.000000 L 36.094235,7.202457 Z\" fill=\"yellow\" opacity=\"0.5\"/><path d=\"M 63.905765,7.202457 A 45.000000,45.000000 0 0 1 86.405765,23.549664 L 50.000000,50.000000 L 63.905765,7.202457 Z\" fill=\"#99F255\" opacity=\"0.5\"/><path d=\"M 90.046341,20.904630 A 49.500000,49.500000 0 0 1 99.500000,50.000000 L 50.000000,50.000000 L 90.046341,20.904630 Z\" fill=\"#44CC11\" opacity=\"1\"/><path d=\"M50,50 L25,50 A25,25 0 1,1 75,50 Z\" fill=\"white\"/><polygon points=\"54.38889075707997,54.852857095672256 54.38889075707996,45.14714290432774 95,49.999999999999986\" fill=\"black\" /><path d=\"M50,50 L40,50 A10,10 0 1,1 60,50 Z\" fill=\"black\"/><path d=\"M50,50 L45,50 A5,5 0 1,1 55.00000000000001,50 Z\" fill=\"#7A7A7A\"/></svg>"},                                  // Clamp percentage to 100
+		{"100", "50", "<svg height=\"50px\" width=\"100px\" viewBox=\"0 0 100 50\" xmlns=\"http://www.w3.org/2000/svg\" role=\"img\" aria-label=\"Progress: 50 percent\"><title>Progress: 50 percent</title><path d=\"M 5.000000,50.000000 A 45.000000,45.000000 0 0 1 13.594235,23.549664 L 50.000000,50.000000 L 5.000000,50.000000 Z\" fill=\"red\" opacity=\"0.5\"/><path d=\"M 13.594235,23.549664 A 45.000000,45.000000 0 0 1 36.094235,7.202457 L 50.000000,50.000000 L 13.594235,23.549664 Z\" fill=\"orange\" opacity=\"0.5\"/><path d=\"M 34.703659,2.922702 A 49.500000,49.500000 0 0 1 65.296341,2.922702 L 50.000000,50.000000 L 34.703659,2.922702 Z\" fill=\"yellow\" opacity=\"1\"/><path d=\"M 63.905765,7.202457 A 45.000000,45.000000 0 0 1 86.405765,23.549664 L 50.000000,50.000000 L 63.905765,7.202457 Z\" fill=\"#99F255\" opacity=\"0.5\"/><path d=\"M 86.405765,23.549664 A 45.000000,45.000000 0 0 1 95.000000,50.000000 L 50.000000,50.000000 L 86.405765,23.549664 Z\" fill=\"#44CC11\" opacity=\"0.5\"/><path d=\"M50,50 L25,50 A25,25 0 1,1 75,50 Z\" fill=\"white\"/><polygon points=\"54.852857095672256,45.61110924292003 45.14714290432774,45.61110924292004 49.999999999999986,5\" fill=\"black\" /><path d=\"M50,50 L40,50 A10,10 0 1,1 60,50 Z\" fill=\"black\"/><path d=\"M50,50 L45,50 A5,5 0 1,1 55.00000000000001,50 Z\" fill=\"#7A7A7A\"/></svg>"},
+		{"", "75", "<svg height=\"50px\" width=\"100px\" viewBox=\"0 0 100 50\" xmlns=\"http://www.w3.org/2000/svg\" role=\"img\" aria-label=\"Progress: 75 percent\"><title>Progress: 75 percent</title><path d=\"M 5.000000,50.000000 A 45.000000,45.000000 0 0 1 13.594235,23.549664 L 50.000000,50.000000 L 5.000000,50.000000 Z\" fill=\"red\" opacity=\"0.5\"/><path d=\"M 13.594235,23.549664 A 45.000000,45.000000 0 0 1 36.094235,7.202457 L 50.000000,50.000000 L 13.594235,23.549664 Z\" fill=\"orange\" opacity=\"0.5\"/><path d=\"M 36.094235,7.202457 A 45.000000,45.000000 0 0 1 63.905765,7.202457 L 50.000000,50.000000 L 36.094235,7.202457 Z\" fill=\"yellow\" opacity=\"0.5\"/><path d=\"M 65.296341,2.922702 A 49.500000,49.500000 0 0 1 90.046341,20.904630 L 50.000000,50.000000 L 65.296341,2.922702 Z\" fill=\"#99F255\" opacity=\"1\"/><path d=\"M 86.405765,23.549664 A 45.000000,45.000000 0 0 1 95.000000,50.000000 L 50.000000,50.000000 L 86.405765,23.549664 Z\" fill=\"#44CC11\" opacity=\"0.5\"/><path d=\"M50,50 L25,50 A25,25 0 1,1 75,50 Z\" fill=\"white\"/><polygon points=\"56.53490257669732,50.328073744260905 49.6719262557391,43.46509742330269 81.81980515339464,18.180194846605357\" fill=\"black\" /><path d=\"M50,50 L40,50 A10,10 0 1,1 60,50 Z\" fill=\"black\"/><path d=\"M50,50 L45,50 A5,5 0 1,1 55.00000000000001,50 Z\" fill=\"#7A7A7A\"/></svg>"},                // Defaults to width=100
+		{"200", "", "<svg height=\"100px\" width=\"200px\" viewBox=\"0 0 200 100\" xmlns=\"http://www.w3.org/2000/svg\" role=\"img\" aria-label=\"Progress: 0 percent\"><title>Progress: 0 percent</title><path d=\"M 1.000000,100.000000 A 99.000000,99.000000 0 0 1 19.907318,41.809260 L 100.000000,100.000000 L 1.000000,100.000000 Z\" fill=\"red\" opacity=\"1\"/><path d=\"M 27.188471,47.099327 A 90.000000,90.000000 0 0 1 72.188471,14.404914 L 100.000000,100.000000 L 27.188471,47.099327 Z\" fill=\"orange\" opacity=\"0.5\"/><path d=\"M 72.188471,14.404914 A 90.000000,90.000000 0 0 1 127.811529,14.404914 L 100.000000,100.000000 L 72.188471,14.404914 Z\" fill=\"yellow\" opacity=\"0.5\"/><path d=\"M 127.811529,14.404914 A 90.000000,90.000000 0 0 1 172.811529,47.099327 L 100.000000,100.000000 L 127.811529,14.404914 Z\" fill=\"#99F255\" opacity=\"0.5\"/><path d=\"M 172.811529,47.099327 A 90.000000,90.000000 0 0 1 190.000000,100.000000 L 100.000000,100.000000 L 172.811529,47.099327 Z\" fill=\"#44CC11\" opacity=\"0.5\"/><path d=\"M100,100 L50,100 A50,50 0 1,1 150,100 Z\" fill=\"white\"/><polygon points=\"91.22221848584006,90.29428580865546 91.22221848584006,109.70571419134453 10,100\" fill=\"black\" /><path d=\"M100,100 L80,100 A20,20 0 1,1 120,100 Z\" fill=\"black\"/><path d=\"M100,100 L90,100 A10,10 0 1,1 110.00000000000001,100 Z\" fill=\"#7A7A7A\"/></svg>"}, // Defaults to percentage=0
+		{"100", "101", "<svg height=\"50px\" width=\"100px\" viewBox=\"0 0 100 50\" xmlns=\"http://www.w3.org/2000/svg\" role=\"img\" aria-label=\"Progress: 100 percent\"><title>Progress: 100 percent</title><path d=\"M 5.000000,50.000000 A 45.000000,45.000000 0 0 1 13.594235,23.549664 L 50.000000,50.000000 L 5.000000,50.000000 Z\" fill=\"red\" opacity=\"0.5\"/><path d=\"M 13.594235,23.549664 A 45.000000,45.000000 0 0 1 36.094235,7.202457 L 50.000000,50.000000 L 13.594235,23.549664 Z\" fill=\"orange\" opacity=\"0.5\"/><path d=\"M 36.094235,7.202457 A 45.000000,45.000000 0 0 1 63.905765,7.202457 L 50.000000,50.000000 L 36.094235,7.202457 Z\" fill=\"yellow\" opacity=\"0.5\"/><path d=\"M 63.905765,7.202457 A 45.000000,45.000000 0 0 1 86.405765,23.549664 L 50.000000,50.000000 L 63.905765,7.202457 Z\" fill=\"#99F255\" opacity=\"0.5\"/><path d=\"M 90.046341,20.904630 A 49.500000,49.500000 0 0 1 99.500000,50.000000 L 50.000000,50.000000 L 90.046341,20.904630 Z\" fill=\"#44CC11\" opacity=\"1\"/><path d=\"M50,50 L25,50 A25,25 0 1,1 75,50 Z\" fill=\"white\"/><polygon points=\"54.38889075707997,54.852857095672256 54.38889075707996,45.14714290432774 95,49.999999999999986\" fill=\"black\" /><path d=\"M50,50 L40,50 A10,10 0 1,1 60,50 Z\" fill=\"black\"/><path d=\"M50,50 L45,50 A5,5 0 1,1 55.00000000000001,50 Z\" fill=\"#7A7A7A\"/></svg>"},                        // Clamp percentage to 100
 	}
 
 	preprocessSVG := func(svg string) string {
@@ -83,6 +82,78 @@ func TestHandleProgressGauge(t *testing.T) {
 	// Additional checks can be added to verify specific aspects of the SVG output
 }
 
+func TestHandleProgressGaugeInvalidWidth(t *testing.T) {
+	router := gin.Default()
+	router.GET("/test", HandleProgressGauge)
+
+	req, _ := http.NewRequest("GET", "/test?width=-10&percentage=30", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, resp.Code)
+	}
+	if !strings.Contains(resp.Body.String(), "Width must be between 1 and 10000") {
+		t.Errorf("Expected width error, got %s", resp.Body.String())
+	}
+}
+
+func TestHandleProgressGaugeAnimation(t *testing.T) {
+	router := gin.Default()
+	router.GET("/test", HandleProgressGauge)
+
+	testCases := []struct {
+		name             string
+		queryString      string
+		expectedInBody   []string
+		unexpectedInBody []string
+	}{
+		{
+			name:        "Needle turns from zero",
+			queryString: "/test?width=100&percentage=50&animate=true&easing=linear",
+			expectedInBody: []string{
+				`fill="black">`,
+				`<animateTransform attributeName="transform" type="rotate" values="-90 50 50;0 50 50" dur="1s" calcMode="spline" keyTimes="0;1" keySplines="0 0 1 1" fill="freeze" />`,
+				`</polygon>`,
+			},
+		},
+		{
+			name:           "Start angle follows the percentage",
+			queryString:    "/test?width=200&percentage=25&animate=true&duration=2",
+			expectedInBody: []string{`values="-45 100 100;0 100 100" dur="2s"`, `keySplines="0 0 0.58 1"`},
+		},
+		{
+			name:             "Animation off",
+			queryString:      "/test?width=100&percentage=50&animate=false&duration=2",
+			expectedInBody:   []string{`49.999999999999986,5" fill="black" />`},
+			unexpectedInBody: []string{"<animateTransform", "</polygon>"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tc.queryString, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.Code)
+			}
+			body := resp.Body.String()
+			for _, str := range tc.expectedInBody {
+				if !strings.Contains(body, str) {
+					t.Errorf("Expected to find %s in response body", str)
+				}
+			}
+			for _, str := range tc.unexpectedInBody {
+				if strings.Contains(body, str) {
+					t.Errorf("Expected not to find %s in response body", str)
+				}
+			}
+		})
+	}
+}
+
 func TestCreatePiePath(t *testing.T) {
 	tests := []struct {
 		center     float64
@@ -111,7 +182,7 @@ func TestCreatePiePath(t *testing.T) {
 func TestCalculateNeedlePosition(t *testing.T) {
 	tests := []struct {
 		center     float64
-		percentage int
+		percentage float64
 		expectedX1 float64
 		expectedY1 float64
 		expectedX2 float64
@@ -132,7 +203,7 @@ func TestCalculateNeedlePosition(t *testing.T) {
 			floatEquals(needle.X3, test.expectedX3) && floatEquals(needle.Y3, test.expectedY3) {
 			continue
 		}
-		t.Errorf("calculateNeedlePosition(%f, %d) produced incorrect triangle points\n"+
+		t.Errorf("calculateNeedlePosition(%f, %f) produced incorrect triangle points\n"+
 			"Expected: X1=%f, Y1=%f, X2=%f, Y2=%f, X3=%f, Y3=%f\n"+
 			"Actual: X1=%f, Y1=%f, X2=%f, Y2=%f, X3=%f, Y3=%f",
 			test.center, test.percentage,
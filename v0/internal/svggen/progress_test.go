@@ -0,0 +1,68 @@
+package svggen
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestReadPercentage(t *testing.T) {
+	testCases := []struct {
+		name        string
+		query       string
+		expected    float64
+		expectError bool
+	}{
+		{"Default", "", 12, false},
+		{"Whole percentage", "percentage=72", 72, false},
+		{"Fractional percentage", "percentage=72.5", 72.5, false},
+		{"Empty percentage", "percentage=", 12, false},
+		{"Value of default max", "value=40", 40, false},
+		{"Value of max", "value=37&max=52", 37.0 / 52 * 100, false},
+		{"Value between min and max", "value=15&min=10&max=20", 50, false},
+		{"Value wins over percentage", "value=1&max=4&percentage=90", 25, false},
+		{"Invalid percentage", "percentage=abc", 0, true},
+		{"Infinite value", "value=Inf", 0, true},
+		{"Max equal to min", "value=1&min=5&max=5", 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			params, _ := url.ParseQuery(tc.query)
+			r := newParamReader(params)
+			result := readPercentage(r, 12)
+			err := r.Err()
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected an error, got %f", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !floatEquals(result, tc.expected) {
+				t.Errorf("Expected %f, got %f", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestFormatPercentage(t *testing.T) {
+	testCases := []struct {
+		percentage float64
+		decimals   int
+		expected   string
+	}{
+		{54, 0, "54%"},
+		{72.5, 0, "73%"},
+		{72.5, 1, "72.5%"},
+		{71.15384615, 2, "71.15%"},
+		{100, 2, "100.00%"},
+	}
+
+	for _, tc := range testCases {
+		if result := formatPercentage(tc.percentage, tc.decimals); result != tc.expected {
+			t.Errorf("formatPercentage(%f, %d) = %s; expected %s", tc.percentage, tc.decimals, result, tc.expected)
+		}
+	}
+}
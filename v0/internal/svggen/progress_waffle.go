@@ -1,15 +1,17 @@
 package svggen
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"html/template"
-	"log"
+	"io"
 	"math"
-	"strconv"
+	"net/url"
 )
 
 const waffleChartTemplateStr = `
-<svg width="{{.Width}}px" height="{{.Height}}px" xmlns="http://www.w3.org/2000/svg">
+<svg width="{{.Width}}px" height="{{.Height}}px" xmlns="http://www.w3.org/2000/svg" role="img" aria-label="{{.A11y.Title}}">
+	<title>{{.A11y.Title}}</title>{{with .A11y.Desc}}<desc>{{.}}</desc>{{end}}{{.Style}}
 	<rect x="0" y="0" width="{{.Width}}px" height="{{.Height}}px" fill="none" />
     {{range .Squares}}
         <rect class="gridSquare" x="{{.X}}px" y="{{.Y}}px" width="{{$.SquareSize}}px" height="{{$.SquareSize}}px" fill="{{.Color}}" />
@@ -95,10 +97,71 @@ func GenerateSquares(width, squaresPerRow, numberOfSquares, filledSquares, gap i
 	return squares
 }
 
+// WaffleOptions configures a waffle progress chart.
+type WaffleOptions struct {
+	Width           int            // Width of the grid in pixels
+	NumberOfSquares int            // Total number of squares in the grid
+	Gap             int            // Space between squares in pixels; 0 for the default of 3
+	Percentage      float64        // Share of filled squares, clamped to 0-100
+	Thresholds      []Threshold    // Colors of the filled squares by percentage, replacing the theme's fill when not empty
+	Invert          bool           // Read the thresholds from 100%, for values where lower is better
+	Theme           string         // Name of the color theme, see Themes
+	Colors          ColorOverrides // Colors replacing those of the theme
+	Title, Desc     string         // Accessible title and description, generated from the chart when empty
+}
+
+// defaultWaffleGap is the space between squares when the options do not set one.
+const defaultWaffleGap = 3
+
+// DefaultWaffleOptions returns the options used when a parameter is not provided.
+func DefaultWaffleOptions() WaffleOptions {
+	return WaffleOptions{Width: 100, NumberOfSquares: 100, Gap: defaultWaffleGap}
+}
+
 func HandleProgressWaffle(c *gin.Context) {
-	width := parseOrDefault(c.DefaultQuery("width", "100"), 10) // Minimum width is 10
-	numberOfSquares := parseOrDefault(c.DefaultQuery("numberOfSquares", "100"), 100)
-	gap := 3 // Gap between squares
+	handleChart(c, renderProgressWaffle, nil)
+}
+
+func renderProgressWaffle(w io.Writer, params url.Values) error {
+	opts := settings.Waffle
+	r := newParamReader(params)
+	opts.Width = r.Int("width", opts.Width, 10, settings.MaxChartSize) // Minimum width is 10
+	opts.NumberOfSquares = r.Int("numberOfSquares", opts.NumberOfSquares, 1, settings.MaxWaffleSquares)
+	opts.Percentage = readPercentage(r, opts.Percentage)
+	opts.Thresholds, opts.Invert = readThresholds(r)
+	opts.Theme = readTheme(r, settings.Theme)
+	opts.Colors = readColorOverrides(r)
+	opts.Title, opts.Desc = readAccessibility(r)
+	if err := r.Err(); err != nil {
+		return err
+	}
+	return RenderWaffle(w, opts)
+}
+
+// RenderWaffle writes a waffle progress chart SVG to w.
+// Returns a *ParamError if Width is below 10, NumberOfSquares is outside 1 to the
+// MaxWaffleSquares setting, the gap is negative, the theme is unknown or a color or a threshold is invalid.
+func RenderWaffle(w io.Writer, opts WaffleOptions) error {
+	if opts.Width < 10 {
+		return &ParamError{"Width must be at least 10"}
+	}
+	if opts.NumberOfSquares < 1 || opts.NumberOfSquares > settings.MaxWaffleSquares {
+		return &ParamError{fmt.Sprintf("Number of squares must be between 1 and %d", settings.MaxWaffleSquares)}
+	}
+	theme, err := resolveTheme(opts.Theme, opts.Colors)
+	if err != nil {
+		return err
+	}
+	if err := checkThresholds(opts.Thresholds); err != nil {
+		return err
+	}
+	if opts.Gap < 0 {
+		return &ParamError{"Gap must not be negative"}
+	}
+	if opts.Gap == 0 {
+		opts.Gap = defaultWaffleGap
+	}
+	width, numberOfSquares, gap := opts.Width, opts.NumberOfSquares, opts.Gap
 
 	squaresPerRow, squaresPerColumn := CalculateGridSize(width, numberOfSquares, gap)
 
@@ -111,33 +174,35 @@ func HandleProgressWaffle(c *gin.Context) {
 
 	height := (squareSize+gap)*squaresPerColumn - gap // Adjust the height to include gaps between rows
 
-	percentage := clamp(parseOrDefault(c.DefaultQuery("percentage", "0"), 0), 0, 100)
-	filledSquares := numberOfSquares * percentage / 100
+	percentage := clampPercentage(opts.Percentage)
+	filledSquares := int(float64(numberOfSquares) * percentage / 100)
 
 	squares := GenerateSquares(width, squaresPerRow, numberOfSquares, filledSquares, gap)
+	fill := thresholdColor(opts.Thresholds, opts.Invert, percentage, theme.Fill)
+	for i := range squares {
+		squares[i].Color = theme.Track
+		if i < filledSquares {
+			squares[i].Color = fill
+		}
+	}
 
 	data := struct {
+		Style                     template.HTML
+		A11y                      accessibility
 		Width, Height, SquareSize int
 		Squares                   []struct {
 			X, Y  int
 			Color string
 		}
 	}{
+		Style: theme.Style(),
+		A11y: describe(opts.Title, opts.Desc, "Progress: "+percentText(percentage, 0),
+			fmt.Sprintf("%d of %d squares filled", filledSquares, numberOfSquares)),
 		Width:      ((squareSize + gap) * squaresPerRow) + gap,
 		Height:     height + 2*gap,
 		SquareSize: squareSize,
 		Squares:    squares,
 	}
 
-	c.Writer.Header().Set("Content-Type", "image/svg+xml")
-	if err := waffleChartTemplate.Execute(c.Writer, data); err != nil {
-		log.Printf("Error executing waffle chart template: %v\n", err)
-	}
-}
-
-func parseOrDefault(value string, defaultVal int) int {
-	if parsed, err := strconv.Atoi(value); err == nil {
-		return parsed
-	}
-	return defaultVal
+	return waffleChartTemplate.Execute(w, data)
 }
@@ -233,14 +233,14 @@ func TestHandleProgressWaffle(t *testing.T) {
 		{
 			name:             "Zero squares",
 			query:            "/progress/waffle?width=200&numberOfSquares=0&percentage=50",
-			expectedStatus:   http.StatusOK,
+			expectedStatus:   http.StatusBadRequest,
 			expectedFilled:   0,
 			expectedUnfilled: 0,
 		},
 		{
 			name:             "Negative width",
 			query:            "/progress/waffle?width=-200&numberOfSquares=100&percentage=50",
-			expectedStatus:   http.StatusOK,
+			expectedStatus:   http.StatusBadRequest,
 			expectedFilled:   50,
 			expectedUnfilled: 50,
 		},
@@ -257,6 +257,10 @@ func TestHandleProgressWaffle(t *testing.T) {
 				t.Errorf("Expected status %d, got %d for test %s", tc.expectedStatus, w.Code, tc.name)
 			}
 
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
 			// Validate the response body
 			responseBody := w.Body.String()
 			if err := validateWaffleSVG(responseBody, tc.expectedFilled, tc.expectedUnfilled); err != nil {
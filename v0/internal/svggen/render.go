@@ -0,0 +1,244 @@
+package svggen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/rasterize"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RenderFunc renders a chart described by query parameters into w.
+type RenderFunc func(w io.Writer, params url.Values) error
+
+// Charts maps each chart name to its renderer. The HTTP routes and the
+// command line renderer both go through these functions so their output is identical.
+var Charts = map[string]RenderFunc{
+	"badge":         renderBadge,
+	"bar":           renderProgressBar,
+	"calendar":      renderCalendar,
+	"calendar-year": renderCalendarYear,
+	"circle":        renderProgressCircle,
+	"gauge":         renderProgressGauge,
+	"waffle":        renderProgressWaffle,
+}
+
+// ChartNames returns the names of all registered charts in sorted order.
+func ChartNames() []string {
+	names := make([]string, 0, len(Charts))
+	for name := range Charts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RenderChart renders the chart registered under name into w.
+// Returns an error if the chart is unknown or the parameters are invalid.
+func RenderChart(w io.Writer, name string, params url.Values) error {
+	render, ok := Charts[name]
+	if !ok {
+		return fmt.Errorf("unknown chart %q", name)
+	}
+	return render(w, params)
+}
+
+// Output formats supported by every chart.
+const (
+	FormatSVG = "svg"
+	FormatPNG = "png"
+)
+
+var contentTypes = map[string]string{
+	FormatSVG: "image/svg+xml",
+	FormatPNG: "image/png",
+}
+
+// Encode writes a rendered SVG chart to w in the given format.
+// Returns a *ParamError if the format is unknown or the chart is too large or complex to rasterize.
+func Encode(w io.Writer, svg []byte, format string) error {
+	switch format {
+	case FormatSVG:
+		_, err := w.Write(svg)
+		return err
+	case FormatPNG:
+		err := rasterize.PNG(w, bytes.NewReader(svg))
+		if errors.Is(err, rasterize.ErrImageSize) || errors.Is(err, rasterize.ErrTooComplex) {
+			return &ParamError{fmt.Sprintf("Cannot render PNG: %v", err)}
+		}
+		return err
+	}
+	return &ParamError{fmt.Sprintf("Invalid format: %s (must be svg or png)", format)}
+}
+
+// ParamError reports a request parameter that could not be used to render a chart.
+type ParamError struct {
+	Message string
+}
+
+func (e *ParamError) Error() string {
+	return e.Message
+}
+
+// queryOrDefault returns the first value of key in params,
+// or defaultValue if the key is not present.
+func queryOrDefault(params url.Values, key, defaultValue string) string {
+	if values, ok := params[key]; ok && len(values) > 0 {
+		return values[0]
+	}
+	return defaultValue
+}
+
+// RenderErrorKey is the gin context key set when a chart cannot be rendered. Its value
+// is "params" for invalid parameters and "internal" for anything else, so middleware
+// can tell failed renders apart even when an error badge is sent with a 200 status.
+const RenderErrorKey = "svggen.renderError"
+
+// ErrorResponse is the JSON body returned when a chart cannot be rendered.
+type ErrorResponse struct {
+	Error  string       `json:"error"`
+	Params []FieldError `json:"params,omitempty"` // Every invalid query parameter, when the request had any
+}
+
+// newErrorResponse describes err for the client, along with the HTTP status to send.
+// Parameter problems are the client's fault and get a 400, anything else a 500.
+func newErrorResponse(err error) (ErrorResponse, int) {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return ErrorResponse{Error: validationErr.Error(), Params: validationErr.Errors}, http.StatusBadRequest
+	}
+	var paramErr *ParamError
+	if errors.As(err, &paramErr) {
+		return ErrorResponse{Error: paramErr.Message}, http.StatusBadRequest
+	}
+	return ErrorResponse{Error: fmt.Sprintf("Error rendering chart: %v", err)}, http.StatusInternalServerError
+}
+
+// handleChart adapts a RenderFunc to a gin handler.
+// The chart is rendered into a buffer first so a failed render never sends a partial image.
+// usesToday, which may be nil, picks the short max-age of the cache policy and keys the ETag
+// by the current date; a request that already holds the chart gets a 304 without a render.
+// Charts are kept in the render cache under their ETag, so identical URLs render once.
+func handleChart(c *gin.Context, render RenderFunc, usesToday usesTodayFunc) {
+	format := requestFormat(c)
+	if format == FormatPNG && !settings.PNG {
+		handleChartError(c, &ParamError{"PNG output is disabled on this server"}, FormatSVG)
+		return
+	}
+	etag, notModified := setCacheHeaders(c, format, usesToday)
+	if notModified {
+		return
+	}
+	if contentType, body, ok := renderedCharts.get(etag); ok {
+		c.Data(http.StatusOK, contentType, body)
+		return
+	}
+
+	var svg, out bytes.Buffer
+	err := render(&svg, c.Request.URL.Query())
+	if err == nil {
+		err = Encode(&out, svg.Bytes(), format)
+	}
+	if err != nil {
+		handleChartError(c, err, format)
+		return
+	}
+	renderedCharts.add(etag, contentTypes[format], out.Bytes())
+	c.Data(http.StatusOK, contentTypes[format], out.Bytes())
+}
+
+// handleChartError responds with a JSON ErrorResponse, or with an error badge image when
+// the request has onError=badge. Badges are sent with a 200 status because image proxies,
+// such as the one GitHub puts in front of README images, do not display error responses.
+// Errors are never cached, so a fixed request or a new release shows up right away.
+func handleChartError(c *gin.Context, err error, format string) {
+	c.Writer.Header().Del("ETag")
+	c.Header("Cache-Control", "no-store")
+	response, status := newErrorResponse(err)
+	if status == http.StatusBadRequest {
+		c.Set(RenderErrorKey, "params")
+	} else {
+		c.Set(RenderErrorKey, "internal")
+	}
+	if c.Query("onError") != "badge" {
+		c.JSON(status, response)
+		return
+	}
+
+	var svg, out bytes.Buffer
+	if badgeErr := renderErrorBadge(&svg, response.Error); badgeErr != nil {
+		c.JSON(status, response)
+		return
+	}
+	body := svg.Bytes()
+	if _, ok := contentTypes[format]; ok && Encode(&out, svg.Bytes(), format) == nil {
+		body = out.Bytes()
+	} else {
+		format = FormatSVG
+	}
+	c.Data(http.StatusOK, contentTypes[format], body)
+}
+
+// requestFormat returns the output format chosen by the format query parameter,
+// or negotiated from the Accept header when the parameter is absent.
+// PNG is only chosen when the client prefers it over SVG by q-value, so wildcards,
+// ties, a missing Accept header and servers with PNG disabled select SVG.
+func requestFormat(c *gin.Context) string {
+	if format, ok := c.GetQuery("format"); ok {
+		return format
+	}
+	if !settings.PNG {
+		return FormatSVG
+	}
+	c.Header("Vary", "Accept")
+	accept := c.GetHeader("Accept")
+	if acceptQuality(accept, contentTypes[FormatPNG]) > acceptQuality(accept, contentTypes[FormatSVG]) {
+		return FormatPNG
+	}
+	return FormatSVG
+}
+
+// acceptQuality returns the q-value an Accept header gives mediaType, taken from the
+// most specific matching range: the exact type, then type/*, then */*.
+// Returns 0 when nothing matches.
+func acceptQuality(accept, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, 0
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaRange := strings.ToLower(strings.TrimSpace(params[0]))
+		var rank int
+		switch mediaRange {
+		case mediaType:
+			rank = 3
+		case mainType + "/*":
+			rank = 2
+		case "*/*":
+			rank = 1
+		default:
+			continue
+		}
+		if rank < specificity {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if v, err := strconv.ParseFloat(value, 64); err == nil && v >= 0 && v <= 1 {
+					q = v
+				}
+			}
+		}
+		if rank > specificity || q > quality {
+			quality, specificity = q, rank
+		}
+	}
+	return quality
+}
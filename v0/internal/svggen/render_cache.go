@@ -0,0 +1,100 @@
+package svggen
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultRenderCacheSize is the number of rendered charts kept in memory by default.
+const DefaultRenderCacheSize = 1024
+
+// RenderCacheStats counts the lookups of the render cache since the server started.
+type RenderCacheStats struct {
+	Hits, Misses uint64
+	Entries      int // Charts currently held
+	Size         int // Largest number of charts held, 0 when the cache is disabled
+}
+
+// renderCache is a least recently used cache of encoded charts, keyed by the ETag of the
+// response, which already covers the chart, its normalized parameters and the format.
+// Only successful renders are stored, so an error is always reported afresh.
+type renderCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // Front is the most recently used
+	entries map[string]*list.Element
+	hits    uint64
+	misses  uint64
+}
+
+type renderCacheEntry struct {
+	key         string
+	contentType string
+	body        []byte
+}
+
+func newRenderCache(size int) *renderCache {
+	return &renderCache{size: size, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+var renderedCharts = newRenderCache(DefaultRenderCacheSize)
+
+// SetRenderCacheSize empties the render cache and bounds it to size charts.
+// A size of zero disables the cache. It is meant to be called once at startup.
+func SetRenderCacheSize(size int) {
+	renderedCharts.resize(max(size, 0))
+}
+
+// RenderCache returns the hit and miss counts and the occupancy of the render cache.
+func RenderCache() RenderCacheStats {
+	renderedCharts.mu.Lock()
+	defer renderedCharts.mu.Unlock()
+	return RenderCacheStats{
+		Hits:    renderedCharts.hits,
+		Misses:  renderedCharts.misses,
+		Entries: renderedCharts.order.Len(),
+		Size:    renderedCharts.size,
+	}
+}
+
+func (c *renderCache) resize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.size = size
+	c.order.Init()
+	clear(c.entries)
+}
+
+// get returns the cached chart for key and marks it as recently used.
+func (c *renderCache) get(key string) (contentType string, body []byte, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return "", nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(element)
+	entry := element.Value.(*renderCacheEntry)
+	return entry.contentType, entry.body, true
+}
+
+// add stores a chart, evicting the least recently used one when the cache is full.
+func (c *renderCache) add(key, contentType string, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size == 0 {
+		return
+	}
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		return
+	}
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*renderCacheEntry).key)
+	}
+	c.entries[key] = c.order.PushFront(&renderCacheEntry{key: key, contentType: contentType, body: body})
+}
@@ -0,0 +1,81 @@
+package svggen
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRenderCache(t *testing.T) {
+	cache := newRenderCache(2)
+	cache.add("a", "image/svg+xml", []byte("A"))
+	cache.add("b", "image/svg+xml", []byte("B"))
+	cache.get("a") // b is now the least recently used
+	cache.add("c", "image/png", []byte("C"))
+
+	testCases := []struct {
+		key          string
+		expectedBody string
+		expectedOk   bool
+	}{
+		{"a", "A", true},
+		{"b", "", false},
+		{"c", "C", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			_, body, ok := cache.get(tc.key)
+			if ok != tc.expectedOk || string(body) != tc.expectedBody {
+				t.Errorf("Expected %q %v, got %q %v", tc.expectedBody, tc.expectedOk, body, ok)
+			}
+		})
+	}
+	if cache.hits != 3 || cache.misses != 1 {
+		t.Errorf("Expected 3 hits and 1 miss, got %d hits and %d misses", cache.hits, cache.misses)
+	}
+}
+
+func TestRenderCacheDisabled(t *testing.T) {
+	cache := newRenderCache(0)
+	cache.add("a", "image/svg+xml", []byte("A"))
+	if _, _, ok := cache.get("a"); ok {
+		t.Error("Expected a disabled cache to hold nothing")
+	}
+}
+
+func TestHandleChartRenderCache(t *testing.T) {
+	SetRenderCacheSize(DefaultRenderCacheSize)
+	defer SetRenderCacheSize(DefaultRenderCacheSize)
+	before := RenderCache()
+
+	router := gin.Default()
+	router.GET("/progress/bar", HandleProgressBar)
+	var bodies []string
+	for _, queryString := range []string{
+		"/progress/bar?percentage=42&width=300",
+		"/progress/bar?width=300&percentage=42",
+		"/progress/bar?percentage=abc",
+		"/progress/bar?percentage=abc",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", queryString, nil)
+		router.ServeHTTP(w, req)
+		bodies = append(bodies, w.Body.String())
+	}
+
+	after := RenderCache()
+	if hits := after.Hits - before.Hits; hits != 1 {
+		t.Errorf("Expected 1 hit, got %d", hits)
+	}
+	if misses := after.Misses - before.Misses; misses != 3 {
+		t.Errorf("Expected 3 misses, got %d", misses)
+	}
+	if after.Entries != 1 {
+		t.Errorf("Expected only the valid chart to be cached, got %d entries", after.Entries)
+	}
+	if bodies[0] != bodies[1] {
+		t.Errorf("Expected the cached chart to match the rendered one, got %q and %q", bodies[0], bodies[1])
+	}
+}
@@ -0,0 +1,144 @@
+package svggen
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleChartFormat(t *testing.T) {
+	router := gin.Default()
+	router.GET("/calendar", HandleCalendar)
+	router.GET("/progress/bar", HandleProgressBar)
+	router.GET("/progress/circle", HandleProgressCircle)
+	router.GET("/progress/gauge", HandleProgressGauge)
+	router.GET("/progress/waffle", HandleProgressWaffle)
+
+	testCases := []struct {
+		name                string
+		queryString         string
+		accept              string
+		expectedStatus      int
+		expectedContentType string
+	}{
+		{"Default is SVG", "/progress/bar?percentage=50", "", http.StatusOK, "image/svg+xml"},
+		{"Bar PNG", "/progress/bar?percentage=50&format=png", "", http.StatusOK, "image/png"},
+		{"Circle PNG", "/progress/circle?percentage=50&format=png", "", http.StatusOK, "image/png"},
+		{"Gauge PNG", "/progress/gauge?percentage=50&format=png", "", http.StatusOK, "image/png"},
+		{"Waffle PNG", "/progress/waffle?percentage=50&format=png", "", http.StatusOK, "image/png"},
+		{"Calendar PNG", "/calendar?year=2024&month=2&format=png", "", http.StatusOK, "image/png"},
+		{"Accept PNG", "/progress/circle?percentage=50", "image/png", http.StatusOK, "image/png"},
+		{"Accept browser default", "/progress/circle?percentage=50", "image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8", http.StatusOK, "image/svg+xml"},
+		{"Accept Firefox default", "/progress/circle?percentage=50", "image/avif,image/webp,image/png,image/svg+xml,*/*;q=0.8", http.StatusOK, "image/svg+xml"},
+		{"Accept low q PNG", "/progress/circle?percentage=50", "image/png;q=0.1,image/svg+xml", http.StatusOK, "image/svg+xml"},
+		{"Accept preferred PNG", "/progress/circle?percentage=50", "image/svg+xml;q=0.5,image/png", http.StatusOK, "image/png"},
+		{"Accept rejected SVG", "/progress/circle?percentage=50", "image/svg+xml;q=0,*/*", http.StatusOK, "image/png"},
+		{"Accept wildcard", "/progress/circle?percentage=50", "*/*", http.StatusOK, "image/svg+xml"},
+		{"Format overrides Accept", "/progress/circle?percentage=50&format=svg", "image/png", http.StatusOK, "image/svg+xml"},
+		{"Invalid format", "/progress/bar?format=gif", "", http.StatusBadRequest, "application/json; charset=utf-8"},
+		{"PNG too large", "/progress/bar?width=5000&format=png", "", http.StatusBadRequest, "application/json; charset=utf-8"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.queryString, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != tc.expectedContentType {
+				t.Errorf("Expected Content-Type %q, got %q", tc.expectedContentType, contentType)
+			}
+			if tc.expectedContentType == "image/png" {
+				if _, err := png.Decode(bytes.NewReader(w.Body.Bytes())); err != nil {
+					t.Errorf("Expected a valid PNG: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleChartErrors(t *testing.T) {
+	router := gin.Default()
+	router.GET("/progress/bar", HandleProgressBar)
+	router.GET("/calendar", HandleCalendar)
+
+	t.Run("JSON lists every invalid parameter", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/progress/bar?width=abc&height=0&percentage=72.5.1", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		var response ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Expected a JSON body, got %s", w.Body.String())
+		}
+		expected := []FieldError{
+			{Param: "width", Value: "abc", Message: "Invalid width format: must be a whole number"},
+			{Param: "height", Value: "0", Message: "Height must be between 1 and 10000"},
+			{Param: "percentage", Value: "72.5.1", Message: "Invalid percentage format: must be a number"},
+		}
+		if !reflect.DeepEqual(response.Params, expected) {
+			t.Errorf("Expected params %+v, got %+v", expected, response.Params)
+		}
+		if !strings.Contains(response.Error, "Height must be between 1 and 10000") {
+			t.Errorf("Expected the error to summarize the params, got %q", response.Error)
+		}
+	})
+
+	t.Run("Render errors have no params", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/progress/bar?segments=a:1,b:1,c:1,d:1,e:1,f:1,g:1", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		if body := strings.TrimSpace(w.Body.String()); body != `{"error":"At most 6 segments are supported"}` {
+			t.Errorf("Unexpected body %s", body)
+		}
+	})
+
+	t.Run("Error badge", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/calendar?month=13&onError=badge", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != "image/svg+xml" {
+			t.Errorf("Expected Content-Type image/svg+xml, got %q", contentType)
+		}
+		if !strings.Contains(w.Body.String(), ">Month must be between 1 and 12</text>") {
+			t.Errorf("Expected the error message on the badge, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Error badge as PNG", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/progress/bar?width=abc&format=png&onError=badge", nil)
+		router.ServeHTTP(w, req)
+
+		if contentType := w.Header().Get("Content-Type"); contentType != "image/png" {
+			t.Errorf("Expected Content-Type image/png, got %q", contentType)
+		}
+		if _, err := png.Decode(bytes.NewReader(w.Body.Bytes())); err != nil {
+			t.Errorf("Expected a valid PNG: %v", err)
+		}
+	})
+}
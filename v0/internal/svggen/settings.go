@@ -0,0 +1,48 @@
+package svggen
+
+import (
+	"fmt"
+)
+
+// Settings are the server-wide defaults and limits applied when reading chart requests.
+// The options of each chart are where its request starts before the query parameters are
+// read, so a parameter a request leaves out takes the value given here. Their Theme is
+// replaced by the Theme of the settings, which applies to the calendars as well.
+type Settings struct {
+	Bar    BarOptions
+	Circle CircleOptions
+	Gauge  GaugeOptions
+	Waffle WaffleOptions
+	Theme  string // Theme of every chart whose request does not name one, see Themes
+
+	MaxChartSize     int  // Largest width, height or size in pixels accepted from a request
+	MaxWaffleSquares int  // Largest number of waffle squares accepted from a request
+	PNG              bool // Whether charts can be requested as PNG
+}
+
+// DefaultSettings returns the default options of every chart, the default theme,
+// limits of 10000 and PNG output enabled.
+func DefaultSettings() Settings {
+	return Settings{
+		Bar:              DefaultBarOptions(),
+		Circle:           DefaultCircleOptions(),
+		Gauge:            DefaultGaugeOptions(),
+		Waffle:           DefaultWaffleOptions(),
+		Theme:            DefaultTheme,
+		MaxChartSize:     10000,
+		MaxWaffleSquares: 10000,
+		PNG:              true,
+	}
+}
+
+var (
+	settings   = DefaultSettings()
+	settingsID = fmt.Sprintf("%+v", settings) // Part of every ETag, since the settings change what a request draws
+)
+
+// SetSettings replaces the settings of every chart request. The settings are used as
+// they are, so they should be validated first. It is meant to be called once at startup,
+// before the server handles requests.
+func SetSettings(s Settings) {
+	settings, settingsID = s, fmt.Sprintf("%+v", s)
+}
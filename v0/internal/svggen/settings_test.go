@@ -0,0 +1,76 @@
+package svggen
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSettings(t *testing.T) {
+	s := DefaultSettings()
+	s.Bar.Width = 321
+	s.Circle.StrokeWidth = 5
+	s.Waffle.Gap = 7
+	s.Theme = "dark"
+	s.MaxChartSize = 500
+	s.PNG = false
+	SetSettings(s)
+	defer SetSettings(DefaultSettings())
+	SetRenderCacheSize(DefaultRenderCacheSize)
+	defer SetRenderCacheSize(DefaultRenderCacheSize)
+
+	router := gin.Default()
+	router.GET("/progress/bar", HandleProgressBar)
+	router.GET("/progress/circle", HandleProgressCircle)
+	router.GET("/progress/waffle", HandleProgressWaffle)
+
+	testCases := []struct {
+		name           string
+		queryString    string
+		accept         string
+		expectedStatus int
+		expectedInBody []string
+	}{
+		{"Default width", "/progress/bar?percentage=50", "", http.StatusOK, []string{`<svg width="321px"`, darkTheme.Track}},
+		{"Requested width", "/progress/bar?percentage=50&width=100&theme=default", "", http.StatusOK, []string{`<svg width="100px"`, lightTheme.Track}},
+		{"Size limit", "/progress/bar?width=501", "", http.StatusBadRequest, []string{"Width must be between 1 and 500"}},
+		{"Stroke width", "/progress/circle?percentage=50", "", http.StatusOK, []string{`stroke-width="5"`}},
+		{"Gap", "/progress/waffle?percentage=50&numberOfSquares=4", "", http.StatusOK, []string{`x="7px" y="7px"`}},
+		{"PNG disabled", "/progress/bar?format=png", "", http.StatusBadRequest, []string{"PNG output is disabled on this server"}},
+		{"PNG not negotiated", "/progress/bar", "image/png", http.StatusOK, []string{"<svg"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.queryString, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			for _, expected := range tc.expectedInBody {
+				if !strings.Contains(w.Body.String(), expected) {
+					t.Errorf("Expected body to contain %q, got %s", expected, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestSettingsChangeETag(t *testing.T) {
+	defer SetSettings(DefaultSettings())
+	before := chartETag("/progress/bar", nil, FormatSVG, "")
+	s := DefaultSettings()
+	s.Bar.Width = 321
+	SetSettings(s)
+	if after := chartETag("/progress/bar", nil, FormatSVG, ""); after == before {
+		t.Errorf("Expected the ETag to change with the settings, got %s for both", after)
+	}
+}
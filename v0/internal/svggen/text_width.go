@@ -0,0 +1,52 @@
+package svggen
+
+import (
+	"math"
+	"unicode"
+)
+
+// verdanaWidths holds the advance widths of the printable ASCII characters in Verdana,
+// the font badges are drawn with, in 1/2048 em units starting at the space.
+var verdanaWidths = [...]uint16{
+	720, 824, 1064, 1696, 1392, 2508, 1584, 616, 1024, 1024, 1392, 1696, 720, 980, 720, 1488, // space to /
+	1392, 1392, 1392, 1392, 1392, 1392, 1392, 1392, 1392, 1392, // 0 to 9
+	824, 824, 1696, 1696, 1696, 1188, 2044, // : to @
+	1402, 1413, 1432, 1584, 1294, 1178, 1606, 1548, 862, 921, 1424, 1152, 1744, // A to M
+	1530, 1614, 1223, 1614, 1427, 1394, 1241, 1520, 1402, 2040, 1406, 1241, 1399, // N to Z
+	1024, 1488, 1024, 1696, 1392, 1392, // [ to `
+	1226, 1270, 1060, 1270, 1219, 720, 1270, 1294, 556, 674, 1209, 556, 1985, // a to m
+	1294, 1240, 1270, 1270, 874, 1043, 793, 1294, 1178, 1674, 1178, 1178, 1052, // n to z
+	1300, 1024, 1300, 1696, // { to ~
+}
+
+const (
+	verdanaUnitsPerEm = 2048
+	averageWidth      = 1392 // Used for characters missing from the table, the width of a digit
+	fullWidth         = 2048 // Used for CJK characters, which are a full em wide
+
+	// boldScale approximates how much wider Verdana Bold is than the regular weight.
+	boldScale = 1.12
+)
+
+// textWidth estimates the width in pixels of text drawn in Verdana at fontSize, so a
+// badge can fit its text without a browser to measure it. Characters outside ASCII
+// are given the average width, or a full em for CJK scripts, and combining marks none.
+func textWidth(text string, fontSize float64, bold bool) float64 {
+	units := 0
+	for _, r := range text {
+		switch {
+		case r >= ' ' && int(r-' ') < len(verdanaWidths):
+			units += int(verdanaWidths[r-' '])
+		case unicode.Is(unicode.Mn, r):
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			units += fullWidth
+		default:
+			units += averageWidth
+		}
+	}
+	width := float64(units) * fontSize / verdanaUnitsPerEm
+	if bold {
+		width *= boldScale
+	}
+	return math.Ceil(width)
+}
@@ -0,0 +1,29 @@
+package svggen
+
+import "testing"
+
+func TestTextWidth(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		fontSize float64
+		bold     bool
+		expected float64
+	}{
+		{"Empty", "", 11, false, 0},
+		{"Narrow letters", "iiii", 11, false, 12},
+		{"Wide letters", "WWWW", 11, false, 44},
+		{"Digits", "1234567890", 11, false, 75},
+		{"Bold", "WWWW", 11, true, 50},
+		{"Combining mark", "é", 11, false, 7},
+		{"CJK", "版本", 11, false, 22},
+		{"Other scripts", "αβ", 11, false, 15},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := textWidth(tc.text, tc.fontSize, tc.bold); actual != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package svggen
+
+import (
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+)
+
+// DefaultTheme is used when no theme is given.
+const DefaultTheme = "default"
+
+// theme holds the colors a chart is drawn with, by the role they play.
+// Series colors, intensity ramps and the gauge sections are shared by every theme
+// unless a palette is given.
+type theme struct {
+	Background string   // Behind the chart
+	Text       string   // Labels, the gauge needle and the calendar's today outline
+	MutedText  string   // Secondary labels such as weekday names
+	Track      string   // Unfilled part of a progress chart
+	Fill       string   // Filled part of a progress chart
+	Progress   string   // Calendar days marked as progress
+	OnFill     string   // Labels drawn over the track and fill
+	Empty      string   // Calendar days without progress
+	Border     string   // Outline of calendar days
+	Palette    []string // Colors of multi-color charts, replacing the shared ones when set
+	Dark       *theme   // Used instead when the viewer prefers a dark color scheme, if set
+}
+
+var lightTheme = theme{
+	Background: Colors.White,
+	Text:       Colors.Black,
+	MutedText:  Colors.Grey,
+	Track:      Colors.Grey,
+	Fill:       Colors.Green,
+	Progress:   progressDayColor,
+	OnFill:     Colors.White,
+	Empty:      emptyDayColor,
+	Border:     "#ddd",
+}
+
+var darkTheme = theme{
+	Background: "#0D1117",
+	Text:       "#E6EDF3",
+	MutedText:  "#8B949E",
+	Track:      "#30363D",
+	Fill:       Colors.Green,
+	Progress:   progressDayColor,
+	OnFill:     Colors.White,
+	Empty:      "#161B22",
+	Border:     "#30363D",
+}
+
+// themes are the color schemes that can be picked with the theme parameter.
+var themes = map[string]theme{
+	DefaultTheme: lightTheme,
+	"dark":       darkTheme,
+	"high-contrast": {
+		Background: Colors.White,
+		Text:       Colors.Black,
+		MutedText:  Colors.Black,
+		Track:      "#505050",
+		Fill:       "#1A7F37",
+		Progress:   "#1A7F37",
+		OnFill:     Colors.White,
+		Empty:      Colors.White,
+		Border:     Colors.Black,
+	},
+	"solarized": {
+		Background: "#FDF6E3",
+		Text:       "#586E75",
+		MutedText:  "#93A1A1",
+		Track:      "#93A1A1",
+		Fill:       "#859900",
+		Progress:   "#859900",
+		OnFill:     "#FDF6E3",
+		Empty:      "#EEE8D5",
+		Border:     "#93A1A1",
+	},
+	"solarized-dark": {
+		Background: "#002B36",
+		Text:       "#93A1A1",
+		MutedText:  "#586E75",
+		Track:      "#586E75",
+		Fill:       "#859900",
+		Progress:   "#859900",
+		OnFill:     "#FDF6E3",
+		Empty:      "#073642",
+		Border:     "#586E75",
+	},
+	"auto": withDark(lightTheme, darkTheme),
+}
+
+// withDark returns light with dark as its dark color scheme variant.
+func withDark(light, dark theme) theme {
+	light.Dark = &dark
+	return light
+}
+
+// Themes returns the supported theme names in alphabetical order.
+func Themes() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupTheme returns the theme registered under name. An empty name selects DefaultTheme.
+func lookupTheme(name string) (theme, error) {
+	if name == "" {
+		name = DefaultTheme
+	}
+	if t, ok := themes[name]; ok {
+		return t, nil
+	}
+	return theme{}, &ParamError{fmt.Sprintf("Unknown theme: %s (must be one of %s)", name, strings.Join(Themes(), ", "))}
+}
+
+// resolveTheme returns the theme registered under name with the overrides applied.
+// Returns a *ParamError if the theme is unknown or an override is not a color.
+func resolveTheme(name string, overrides ColorOverrides) (theme, error) {
+	t, err := lookupTheme(name)
+	if err != nil {
+		return theme{}, err
+	}
+	overrides, err = overrides.resolve()
+	if err != nil {
+		return theme{}, err
+	}
+	return overrides.apply(t), nil
+}
+
+// readTheme reads the theme parameter, recording an error if it is not registered.
+func readTheme(r *paramReader, def string) string {
+	name := r.String("theme", def)
+	if _, err := lookupTheme(name); err != nil {
+		r.Fail("theme", err.Error())
+		return def
+	}
+	return name
+}
+
+// Style returns a <style> element that recolors the chart with the dark variant
+// when the viewer prefers a dark color scheme, or nothing for a theme without one.
+// Charts are drawn with presentation attributes, which any CSS rule overrides, so
+// the rules select elements by the color they were drawn with. Text and shapes are
+// selected separately because some roles share a color in one kind only, such as
+// the white background and the white labels on a progress bar.
+// Renderers that ignore CSS, such as the PNG rasterizer, show the light variant.
+func (t theme) Style() template.HTML {
+	if t.Dark == nil {
+		return ""
+	}
+	var rules []string
+	seen := map[string]bool{}
+	add := func(selector, property, light, dark string) {
+		if light == dark {
+			return
+		}
+		rule := fmt.Sprintf(`%s[%s="%s"]{%s:%s}`, selector, property, light, property, dark)
+		if !seen[rule] {
+			seen[rule] = true
+			rules = append(rules, rule)
+		}
+	}
+	text := func(light, dark string) {
+		add("text", "fill", light, dark)
+	}
+	shape := func(light, dark string) {
+		add(":not(text)", "fill", light, dark)
+		add("", "stroke", light, dark)
+	}
+
+	d := t.Dark
+	shape(t.Background, d.Background)
+	text(t.Text, d.Text)
+	shape(t.Text, d.Text)
+	text(t.MutedText, d.MutedText)
+	shape(t.Track, d.Track)
+	shape(t.Fill, d.Fill)
+	shape(t.Progress, d.Progress)
+	text(t.OnFill, d.OnFill)
+	shape(t.Empty, d.Empty)
+	shape(t.Border, d.Border)
+	if len(rules) == 0 {
+		return ""
+	}
+	return template.HTML("<style>@media (prefers-color-scheme: dark){" + strings.Join(rules, "") + "}</style>")
+}
+
+// series returns the colors assigned, in order, to the series of a chart.
+func (t theme) series() []string {
+	if len(t.Palette) > 0 {
+		return t.Palette
+	}
+	return seriesColors()
+}
+
+// ramp returns n shades for counts, from the lowest to the highest.
+func (t theme) ramp(n int) []string {
+	if len(t.Palette) > 0 {
+		return colorRamp(t.Palette, n)
+	}
+	return colorRamp([]string{Colors.LightGreen, Colors.Green, Colors.DarkGreen}, n)
+}
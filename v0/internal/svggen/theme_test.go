@@ -0,0 +1,62 @@
+package svggen
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLookupTheme(t *testing.T) {
+	testCases := []struct {
+		name        string
+		theme       string
+		expected    theme
+		expectError bool
+	}{
+		{"Empty name", "", lightTheme, false},
+		{"Default", "default", lightTheme, false},
+		{"Dark", "dark", darkTheme, false},
+		{"Unknown", "neon", theme{}, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := lookupTheme(tc.theme)
+			if (err != nil) != tc.expectError {
+				t.Fatalf("Expected error %v, got %v", tc.expectError, err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestThemeStyle(t *testing.T) {
+	if style := lightTheme.Style(); style != "" {
+		t.Errorf("Expected no style for a theme without a dark variant, got %s", style)
+	}
+
+	// A selector that maps to two colors would make the dark variant depend on rule order
+	rule := regexp.MustCompile(`([^{}]*\[[a-z]+="[^"]*"\])\{([a-z]+:[^}]*)\}`)
+	for _, name := range Themes() {
+		style := string(themes[name].Style())
+		if themes[name].Dark == nil {
+			continue
+		}
+		if !strings.HasPrefix(style, "<style>@media (prefers-color-scheme: dark){") {
+			t.Errorf("Expected %s to embed a dark color scheme, got %s", name, style)
+		}
+		declarations := map[string]string{}
+		for _, match := range rule.FindAllStringSubmatch(style, -1) {
+			selector, declaration := match[1], match[2]
+			if previous, ok := declarations[selector]; ok && previous != declaration {
+				t.Errorf("Expected one color for %s in %s, got %s and %s", selector, name, previous, declaration)
+			}
+			declarations[selector] = declaration
+		}
+		if len(declarations) == 0 {
+			t.Errorf("Expected %s to recolor something, got %s", name, style)
+		}
+	}
+}
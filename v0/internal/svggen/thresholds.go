@@ -0,0 +1,111 @@
+package svggen
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Threshold colors a progress chart whose percentage is at least From, until the next
+// threshold. The first threshold also covers any percentage below it.
+type Threshold struct {
+	From  float64 // Percentage the color starts at
+	Color string  // A ColorSet name such as "green", or a CSS color
+}
+
+// band is a range of percentages drawn in one color.
+type band struct {
+	From, To float64
+	Color    string
+}
+
+// thresholdBands splits 0-100% into the bands of sorted thresholds. Inverted thresholds
+// are read from 100% down, for metrics where lower is better: 0:red,80:green then
+// colors 0-20% green and the rest red.
+func thresholdBands(thresholds []Threshold, invert bool) []band {
+	bands := make([]band, 0, len(thresholds))
+	for i, threshold := range thresholds {
+		from, to := clampPercentage(threshold.From), 100.0
+		if i == 0 {
+			from = 0
+		}
+		if i+1 < len(thresholds) {
+			to = clampPercentage(thresholds[i+1].From)
+		}
+		if to <= from && (len(bands) > 0 || i+1 < len(thresholds)) {
+			continue // Entirely outside 0-100%
+		}
+		color, _ := namedColor(threshold.Color)
+		bands = append(bands, band{From: from, To: to, Color: color})
+	}
+	if invert {
+		for i, j := 0, len(bands)-1; i < j; i, j = i+1, j-1 {
+			bands[i], bands[j] = bands[j], bands[i]
+		}
+		for i := range bands {
+			bands[i].From, bands[i].To = 100-bands[i].To, 100-bands[i].From
+		}
+	}
+	return bands
+}
+
+// activeBand returns the index of the band percentage falls in. A percentage on the
+// edge between two bands belongs to the upper one.
+func activeBand(bands []band, percentage float64) int {
+	active := 0
+	for i, b := range bands {
+		if clampPercentage(percentage) >= b.From {
+			active = i
+		}
+	}
+	return active
+}
+
+// thresholdColor returns the color of percentage under thresholds, or def without any.
+func thresholdColor(thresholds []Threshold, invert bool, percentage float64, def string) string {
+	if len(thresholds) == 0 {
+		return def
+	}
+	bands := thresholdBands(thresholds, invert)
+	return bands[activeBand(bands, percentage)].Color
+}
+
+// checkThresholds returns a *ParamError if a threshold has an invalid color or the
+// thresholds are not in ascending order.
+func checkThresholds(thresholds []Threshold) error {
+	for i, threshold := range thresholds {
+		if math.IsNaN(threshold.From) || math.IsInf(threshold.From, 0) {
+			return &ParamError{fmt.Sprintf("Threshold %v must be a number", threshold.From)}
+		}
+		if _, ok := namedColor(threshold.Color); !ok {
+			return &ParamError{fmt.Sprintf("Invalid threshold color: %s (must be a hex, rgb() or CSS color name)", threshold.Color)}
+		}
+		if i > 0 && threshold.From <= thresholds[i-1].From {
+			return &ParamError{"Thresholds must be in ascending order"}
+		}
+	}
+	return nil
+}
+
+// readThresholds reads the thresholds parameter, a comma separated list of
+// percentage:color such as 0:red,50:orange,80:green, and the invert flag.
+func readThresholds(r *paramReader) (thresholds []Threshold, invert bool) {
+	invert = r.Bool("invert", false)
+	if !r.Has("thresholds") {
+		return nil, invert
+	}
+	for _, entry := range splitColors(r.String("thresholds", "")) {
+		fromStr, color, ok := strings.Cut(entry, ":")
+		from, err := strconv.ParseFloat(fromStr, 64)
+		if !ok || err != nil || math.IsNaN(from) || math.IsInf(from, 0) {
+			r.Fail("thresholds", fmt.Sprintf("Invalid threshold format: %s (must be percentage:color)", entry))
+			continue
+		}
+		thresholds = append(thresholds, Threshold{From: from, Color: color})
+	}
+	if err := checkThresholds(thresholds); err != nil {
+		r.Fail("thresholds", err.Error())
+	}
+	return thresholds, invert
+}
@@ -0,0 +1,195 @@
+package svggen
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadThresholds(t *testing.T) {
+	testCases := []struct {
+		name           string
+		query          string
+		expected       []Threshold
+		expectedInvert bool
+		expectedError  string
+	}{
+		{"None", "", nil, false, ""},
+		{"Thresholds", "thresholds=0:red,50:orange,80:green", []Threshold{{0, "red"}, {50, "orange"}, {80, "green"}}, false, ""},
+		{"Colors with commas", "thresholds=0:rgb(1,2,3),50.5:%23ABC&invert=true", []Threshold{{0, "rgb(1,2,3)"}, {50.5, "#ABC"}}, true, ""},
+		{"Missing color", "thresholds=0:red,50", nil, false, "Invalid threshold format: 50"},
+		{"Not a number", "thresholds=low:red", nil, false, "Invalid threshold format: low:red"},
+		{"Invalid color", "thresholds=0:red,50:nope", nil, false, "Invalid threshold color: nope"},
+		{"Unsorted", "thresholds=50:red,0:green", nil, false, "Thresholds must be in ascending order"},
+		{"Invalid invert", "invert=maybe", nil, false, "Invalid invert format"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			params, _ := url.ParseQuery(tc.query)
+			r := newParamReader(params)
+			thresholds, invert := readThresholds(r)
+			err := r.Err()
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(thresholds, tc.expected) || invert != tc.expectedInvert {
+				t.Errorf("Expected %v and invert %v, got %v and %v", tc.expected, tc.expectedInvert, thresholds, invert)
+			}
+		})
+	}
+}
+
+func TestThresholdColor(t *testing.T) {
+	thresholds := []Threshold{{0, "red"}, {50, "orange"}, {80, "green"}}
+	testCases := []struct {
+		name       string
+		thresholds []Threshold
+		invert     bool
+		percentage float64
+		expected   string
+	}{
+		{"No thresholds", nil, false, 50, "default"},
+		{"Lowest band", thresholds, false, 5, "red"},
+		{"Edge belongs to the upper band", thresholds, false, 50, "orange"},
+		{"Highest band", thresholds, false, 100, Colors.Green},
+		{"Below the first threshold", []Threshold{{20, "red"}, {60, "blue"}}, false, 5, "red"},
+		{"Above 100", thresholds, false, 150, Colors.Green},
+		{"Threshold beyond 100", []Threshold{{0, "red"}, {150, "green"}}, false, 100, "red"},
+		{"Inverted low value", thresholds, true, 5, Colors.Green},
+		{"Inverted middle value", thresholds, true, 30, "orange"},
+		{"Inverted high value", thresholds, true, 95, "red"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := thresholdColor(tc.thresholds, tc.invert, tc.percentage, "default"); actual != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// TestProgressThresholds tests the thresholds parameter on every progress chart.
+func TestProgressThresholds(t *testing.T) {
+	router := gin.Default()
+	router.GET("/progress/bar", HandleProgressBar)
+	router.GET("/progress/circle", HandleProgressCircle)
+	router.GET("/progress/gauge", HandleProgressGauge)
+	router.GET("/progress/waffle", HandleProgressWaffle)
+
+	const thresholds = "thresholds=0:red,50:orange,80:green"
+	testCases := []struct {
+		name           string
+		queryString    string
+		expectedStatus int
+		expectedInBody []string
+		notInBody      []string
+	}{
+		{
+			name:           "Bar",
+			queryString:    "/progress/bar?percentage=5&" + thresholds,
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`width="10px" height="30px" fill="red" />`},
+			notInBody:      []string{Colors.Green},
+		},
+		{
+			name:           "Inverted bar",
+			queryString:    "/progress/bar?percentage=5&invert=true&" + thresholds,
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{fmt.Sprintf(`width="10px" height="30px" fill="%s" />`, Colors.Green)},
+		},
+		{
+			name:           "Circle",
+			queryString:    "/progress/circle?percentage=60&" + thresholds,
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`stroke="orange"`},
+		},
+		{
+			name:           "Waffle",
+			queryString:    "/progress/waffle?percentage=90&numberOfSquares=10&" + thresholds,
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{fmt.Sprintf(`fill="%s"`, Colors.Green)},
+			notInBody:      []string{`fill="red"`, `fill="orange"`},
+		},
+		{
+			name:           "Gauge sections",
+			queryString:    "/progress/gauge?percentage=60&" + thresholds,
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{`fill="red" opacity="0.5"`, `fill="orange" opacity="1"`, fmt.Sprintf(`fill="%s" opacity="0.5"`, Colors.Green)},
+			notInBody:      []string{`fill="yellow"`},
+		},
+		{
+			name:           "Inverted gauge",
+			queryString:    "/progress/gauge?percentage=10&invert=true&" + thresholds,
+			expectedStatus: http.StatusOK,
+			expectedInBody: []string{fmt.Sprintf(`fill="%s" opacity="1"`, Colors.Green), `fill="red" opacity="0.5"`},
+		},
+		{
+			name:           "Invalid thresholds",
+			queryString:    "/progress/circle?percentage=60&thresholds=80:green,50:orange",
+			expectedStatus: http.StatusBadRequest,
+			expectedInBody: []string{"Thresholds must be in ascending order"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.queryString, nil)
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+
+			body := w.Body.String()
+			for _, str := range tc.expectedInBody {
+				if !strings.Contains(body, str) {
+					t.Errorf("Expected to find %s in response body %s", str, body)
+				}
+			}
+			for _, str := range tc.notInBody {
+				if strings.Contains(body, str) {
+					t.Errorf("Expected not to find %s in response body %s", str, body)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderThresholdErrors(t *testing.T) {
+	thresholds := []Threshold{{0, "green"}, {50, "nope"}}
+	expected := "Invalid threshold color: nope"
+	renders := map[string]func() error{
+		"Bar": func() error {
+			return RenderBar(&strings.Builder{}, BarOptions{Width: 10, Height: 10, Thresholds: thresholds})
+		},
+		"Circle": func() error {
+			return RenderCircle(&strings.Builder{}, CircleOptions{Size: 100, Thresholds: thresholds})
+		},
+		"Gauge": func() error { return RenderGauge(&strings.Builder{}, GaugeOptions{Width: 100, Thresholds: thresholds}) },
+		"Waffle": func() error {
+			return RenderWaffle(&strings.Builder{}, WaffleOptions{Width: 100, NumberOfSquares: 10, Thresholds: thresholds})
+		},
+	}
+	for name, render := range renders {
+		t.Run(name, func(t *testing.T) {
+			if err := render(); err == nil || !strings.HasPrefix(err.Error(), expected) {
+				t.Errorf("Expected error %q, got %v", expected, err)
+			}
+		})
+	}
+}
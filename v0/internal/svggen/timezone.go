@@ -0,0 +1,30 @@
+package svggen
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" // Bundle the timezone database so tz= works on hosts without one
+)
+
+// loadTimezone returns the location named by an IANA timezone such as "America/Denver".
+// An empty name selects the server's local timezone.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, &ParamError{fmt.Sprintf("Unknown timezone: %s", name)}
+	}
+	return loc, nil
+}
+
+// readTimezone reads the tz parameter, falling back to the server's local timezone.
+func readTimezone(r *paramReader) *time.Location {
+	loc, err := loadTimezone(r.String("tz", ""))
+	if err != nil {
+		r.Fail("tz", err.Error())
+		return time.Local
+	}
+	return loc
+}
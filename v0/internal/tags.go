@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/config"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TagProvider finds the tags pointing at a commit, for example in a remote repository.
+type TagProvider interface {
+	TagsForCommit(ctx context.Context, revision string) ([]string, error)
+}
+
+// NewTagProvider returns the TagProvider cfg selects, with every lookup limited to
+// cfg.Timeout and its results cached for cfg.CacheTTL, or nil for TagProviderNone.
+// The GitHub provider lists the repository of the running module unless
+// cfg.GitHub.Repository names another one.
+func NewTagProvider(cfg config.Version) (TagProvider, error) {
+	var provider TagProvider
+	switch cfg.TagProvider {
+	case config.TagProviderNone, "":
+		return nil, nil
+	case config.TagProviderStatic:
+		provider = StaticTags(mergeTags(nil, strings.Split(cfg.StaticTags, ",")))
+	case config.TagProviderGit:
+		provider = GitTags{Dir: cfg.GitDir}
+	case config.TagProviderGitHub:
+		modulePath := buildVersion().Module
+		if cfg.GitHub.Repository != "" {
+			modulePath = "github.com/" + cfg.GitHub.Repository
+		}
+		github, err := NewGitHubTags(modulePath, cfg.GitHub.BaseURL, cfg.GitHub.Token)
+		if err != nil {
+			return nil, err
+		}
+		provider = github
+	default:
+		return nil, fmt.Errorf("unknown tag provider %q", cfg.TagProvider)
+	}
+	return CacheTags(LimitTags(provider, time.Duration(cfg.Timeout)), time.Duration(cfg.CacheTTL)), nil
+}
+
+// maxFailureTTL bounds how long a failed lookup is remembered, so a provider that
+// was briefly unreachable is asked again soon without being asked on every request.
+const maxFailureTTL = time.Minute
+
+type cachedTags struct {
+	tags    []string
+	err     error
+	expires time.Time
+}
+
+type tagCache struct {
+	provider TagProvider
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	results map[string]cachedTags
+}
+
+// CacheTags wraps provider so each revision is looked up at most once per ttl.
+// Failed lookups are remembered for ttl or a minute, whichever is shorter, and lookups
+// whose context ended are not remembered at all.
+func CacheTags(provider TagProvider, ttl time.Duration) TagProvider {
+	return &tagCache{provider: provider, ttl: ttl, now: time.Now, results: map[string]cachedTags{}}
+}
+
+func (c *tagCache) TagsForCommit(ctx context.Context, revision string) ([]string, error) {
+	c.mu.Lock()
+	result, ok := c.results[revision]
+	c.mu.Unlock()
+	if ok && c.now().Before(result.expires) {
+		return result.tags, result.err
+	}
+
+	tags, err := c.provider.TagsForCommit(ctx, revision)
+	if ctx.Err() != nil {
+		return tags, err // The caller gave up, which says nothing about the provider
+	}
+	ttl := c.ttl
+	if err != nil {
+		ttl = min(ttl, maxFailureTTL)
+	}
+	c.mu.Lock()
+	c.results[revision] = cachedTags{tags: tags, err: err, expires: c.now().Add(ttl)}
+	c.mu.Unlock()
+	return tags, err
+}
+
+// StaticTags is a TagProvider returning the same tags for every revision, for deployments
+// that know their release but were built without tags.
+type StaticTags []string
+
+func (s StaticTags) TagsForCommit(ctx context.Context, revision string) ([]string, error) {
+	return s, nil
+}
+
+// timeoutTags bounds every lookup of a provider.
+type timeoutTags struct {
+	provider TagProvider
+	timeout  time.Duration
+}
+
+// LimitTags wraps provider so each lookup is cancelled after timeout.
+func LimitTags(provider TagProvider, timeout time.Duration) TagProvider {
+	return timeoutTags{provider: provider, timeout: timeout}
+}
+
+func (t timeoutTags) TagsForCommit(ctx context.Context, revision string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.provider.TagsForCommit(ctx, revision)
+}
+
+// matchesRevision reports whether the full commit hash sha is revision,
+// which may be abbreviated to at least 7 characters.
+func matchesRevision(sha, revision string) bool {
+	return len(revision) >= 7 && strings.HasPrefix(strings.ToLower(sha), strings.ToLower(revision))
+}
@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GitTags is a TagProvider reading the tags of a local git repository, for servers
+// deployed along with their checkout. It reads loose and packed refs without running git.
+// Annotated tags are followed to their commit through the peeled lines of packed-refs or
+// the loose tag object; an annotated tag whose object is only in a pack file is skipped.
+type GitTags struct {
+	Dir string // Work tree or .git directory
+}
+
+func (g GitTags) TagsForCommit(ctx context.Context, revision string) ([]string, error) {
+	gitDir, err := findGitDir(g.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Loose refs take precedence over packed ones of the same name
+	targets, err := readPackedTags(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	tagsDir := filepath.Join(gitDir, "refs", "tags")
+	err = filepath.WalkDir(tagsDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == tagsDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name, _ := filepath.Rel(tagsDir, path)
+		sha := strings.TrimSpace(string(content))
+		targets[filepath.ToSlash(name)] = []string{sha, peelTagObject(gitDir, sha)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for name, shas := range targets {
+		for _, sha := range shas {
+			if matchesRevision(sha, revision) {
+				result = append(result, name)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// findGitDir returns the git directory of dir: dir/.git, the directory a .git file
+// points to in a linked work tree, or dir itself.
+func findGitDir(dir string) (string, error) {
+	dotGit := filepath.Join(dir, ".git")
+	info, err := os.Stat(dotGit)
+	switch {
+	case err == nil && info.IsDir():
+		return dotGit, nil
+	case err == nil:
+		content, err := os.ReadFile(dotGit)
+		if err != nil {
+			return "", err
+		}
+		gitDir := strings.TrimSpace(strings.TrimPrefix(string(content), "gitdir:"))
+		if !filepath.IsAbs(gitDir) {
+			gitDir = filepath.Join(dir, gitDir)
+		}
+		return gitDir, nil
+	case os.IsNotExist(err):
+		if _, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil {
+			return "", err
+		}
+		return dir, nil
+	}
+	return "", err
+}
+
+// readPackedTags returns the hashes each tag in packed-refs points at: the tag
+// object and, for annotated tags, the commit from the peeled line after it.
+func readPackedTags(gitDir string) (map[string][]string, error) {
+	targets := map[string][]string{}
+	file, err := os.Open(filepath.Join(gitDir, "packed-refs"))
+	if os.IsNotExist(err) {
+		return targets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var last string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if peeled, ok := strings.CutPrefix(line, "^"); ok && last != "" {
+			targets[last] = append(targets[last], peeled)
+			continue
+		}
+		last = ""
+		sha, ref, ok := strings.Cut(line, " ")
+		if name, isTag := strings.CutPrefix(ref, "refs/tags/"); ok && isTag {
+			targets[name] = []string{sha}
+			last = name
+		}
+	}
+	return targets, scanner.Err()
+}
+
+// peelTagObject returns the object an annotated tag points at, or an empty string
+// if sha is not a loose tag object.
+func peelTagObject(gitDir, sha string) string {
+	if len(sha) < 3 {
+		return ""
+	}
+	file, err := os.Open(filepath.Join(gitDir, "objects", sha[:2], sha[2:]))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	reader, err := zlib.NewReader(file)
+	if err != nil {
+		return ""
+	}
+	defer reader.Close()
+
+	// A tag object starts with "tag <size>\x00object <sha>\n"
+	header, err := io.ReadAll(io.LimitReader(reader, 128))
+	if err != nil && len(header) == 0 {
+		return ""
+	}
+	kind, body, ok := bytes.Cut(header, []byte{0})
+	if !ok || !bytes.HasPrefix(kind, []byte("tag ")) {
+		return ""
+	}
+	object, _, _ := bytes.Cut(bytes.TrimPrefix(body, []byte("object ")), []byte("\n"))
+	return string(object)
+}
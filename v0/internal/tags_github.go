@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// DefaultGitHubAPI is the base URL of the public GitHub REST API.
+const DefaultGitHubAPI = "https://api.github.com"
+
+// maxTagPages bounds how many pages of 100 tags a lookup reads, newest first.
+const maxTagPages = 10
+
+// linkNextRegex finds the URL of the next page in a Link header.
+var linkNextRegex = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// GitHubTags is a TagProvider listing the tags of a repository with the GitHub REST API.
+type GitHubTags struct {
+	BaseURL     string       // API root, DefaultGitHubAPI for github.com or https://HOST/api/v3 for GitHub Enterprise
+	Owner, Repo string       // Repository to list the tags of
+	Token       string       // Optional token, raising the rate limit and giving access to private repositories
+	Client      *http.Client // http.DefaultClient when nil
+}
+
+// NewGitHubTags returns a provider for the repository a module path such as
+// "github.com/owner/repo/v0" is hosted at. A module on another host needs an explicit
+// baseURL, as there is no way to tell its API from the path.
+// Returns an error if the path does not name an owner and repository.
+func NewGitHubTags(modulePath, baseURL, token string) (*GitHubTags, error) {
+	host, owner, repo, err := getOwnerAndRepo(modulePath)
+	if err != nil {
+		return nil, err
+	}
+	if baseURL == "" {
+		if host != "github.com" {
+			return nil, fmt.Errorf("module %s is not on github.com, so the GitHub API base URL must be set", modulePath)
+		}
+		baseURL = DefaultGitHubAPI
+	}
+	return &GitHubTags{BaseURL: baseURL, Owner: owner, Repo: repo, Token: token}, nil
+}
+
+// TagsForCommit lists the tags pointing at revision, following the pages of the API.
+// Returns an error if a request fails or a next page link leaves the scheme and host
+// of BaseURL, since the token would be sent along with the request.
+func (g *GitHubTags) TagsForCommit(ctx context.Context, revision string) ([]string, error) {
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	base, err := url.Parse(g.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub API base URL: %w", err)
+	}
+
+	var result []string
+	pageURL := fmt.Sprintf("%s/repos/%s/%s/tags?per_page=100", g.BaseURL, g.Owner, g.Repo)
+	for page := 0; pageURL != "" && page < maxTagPages; page++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		if g.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+g.Token)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var tags []struct {
+			Name   string `json:"name"`
+			Commit struct {
+				SHA string `json:"sha"`
+			} `json:"commit"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("GitHub API request failed with status code: %d", resp.StatusCode)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&tags)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitHub API response: %w", err)
+		}
+
+		for _, tag := range tags {
+			if matchesRevision(tag.Commit.SHA, revision) {
+				result = append(result, tag.Name)
+			}
+		}
+		pageURL = ""
+		if match := linkNextRegex.FindStringSubmatch(resp.Header.Get("Link")); match != nil {
+			next, err := url.Parse(match[1])
+			if err != nil || !strings.EqualFold(next.Scheme, base.Scheme) || !strings.EqualFold(next.Host, base.Host) {
+				return nil, fmt.Errorf("GitHub API returned a next page outside %s: %s", g.BaseURL, match[1])
+			}
+			pageURL = next.String()
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,284 @@
+package internal
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/config"
+)
+
+const (
+	testCommit  = "d2dc93a4c6b1f0e2a8d9c7b5e3f1a0b2c4d6e8f0"
+	otherCommit = "12157c1e0d9f8a7b6c5d4e3f2a1b0c9d8e7f6a5b"
+	tagObject   = "9f8e7d6c5b4a39281706f5e4d3c2b1a098765432"
+)
+
+func TestGitHubTags(t *testing.T) {
+	var authorization []string
+	foreignRequests := 0
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		foreignRequests++
+		fmt.Fprint(w, `[]`)
+	}))
+	defer foreign.Close()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		if r.URL.Path == "/repos/owner/moved/tags" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/moved/tags?page=2>; rel="next"`, foreign.URL))
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		if r.URL.Path != "/repos/owner/repo/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/tags?per_page=100&page=2>; rel="next"`, server.URL))
+			fmt.Fprintf(w, `[{"name": "v1.1.0", "commit": {"sha": %q}}, {"name": "v1.0.0", "commit": {"sha": %q}}]`, testCommit, otherCommit)
+			return
+		}
+		fmt.Fprintf(w, `[{"name": "latest", "commit": {"sha": %q}}]`, testCommit)
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		provider *GitHubTags
+		revision string
+		expected []string
+		err      bool
+	}{
+		{"Tags across pages", &GitHubTags{BaseURL: server.URL, Owner: "owner", Repo: "repo", Token: "secret"}, testCommit, []string{"v1.1.0", "latest"}, false},
+		{"Short revision", &GitHubTags{BaseURL: server.URL, Owner: "owner", Repo: "repo"}, otherCommit[:7], []string{"v1.0.0"}, false},
+		{"Too short revision", &GitHubTags{BaseURL: server.URL, Owner: "owner", Repo: "repo"}, "12", nil, false},
+		{"Unknown repository", &GitHubTags{BaseURL: server.URL, Owner: "owner", Repo: "missing"}, testCommit, nil, true},
+		{"Next page on another host", &GitHubTags{BaseURL: server.URL, Owner: "owner", Repo: "moved", Token: "secret"}, testCommit, nil, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			authorization = nil
+			actual, err := tc.provider.TagsForCommit(context.Background(), tc.revision)
+			if (err != nil) != tc.err {
+				t.Fatalf("Expected error %v, got %v", tc.err, err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+			expected := ""
+			if tc.provider.Token != "" {
+				expected = "Bearer " + tc.provider.Token
+			}
+			for _, header := range authorization {
+				if header != expected {
+					t.Errorf("Expected Authorization %q, got %q", expected, header)
+				}
+			}
+			if foreignRequests != 0 {
+				t.Errorf("Expected no request to another host, got %d", foreignRequests)
+			}
+		})
+	}
+}
+
+func TestNewGitHubTags(t *testing.T) {
+	testCases := []struct {
+		name       string
+		modulePath string
+		baseURL    string
+		expected   *GitHubTags
+		err        bool
+	}{
+		{"github.com", "github.com/owner/repo/v0", "", &GitHubTags{BaseURL: DefaultGitHubAPI, Owner: "owner", Repo: "repo"}, false},
+		{"Enterprise", "git.example.com/owner/repo", "https://git.example.com/api/v3", &GitHubTags{BaseURL: "https://git.example.com/api/v3", Owner: "owner", Repo: "repo"}, false},
+		{"Enterprise without base URL", "git.example.com/owner/repo", "", nil, true},
+		{"Invalid path", "example.com/repo", "", nil, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := NewGitHubTags(tc.modulePath, tc.baseURL, "")
+			if (err != nil) != tc.err {
+				t.Fatalf("Expected error %v, got %v", tc.err, err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// writeFile creates path with its parent directories.
+func writeFile(t *testing.T, path string, content []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGitTags(t *testing.T) {
+	dir := t.TempDir()
+	gitDir := filepath.Join(dir, ".git")
+	writeFile(t, filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/main\n"))
+	writeFile(t, filepath.Join(gitDir, "packed-refs"), []byte("# pack-refs with: peeled fully-peeled sorted\n"+
+		otherCommit+" refs/heads/main\n"+
+		otherCommit+" refs/tags/v1.0.0\n"+
+		"0000000000000000000000000000000000000001 refs/tags/v1.1.0\n"+
+		"^"+testCommit+"\n"+
+		testCommit+" refs/tags/replaced\n"))
+	writeFile(t, filepath.Join(gitDir, "refs", "tags", "release", "v1.2.0"), []byte(testCommit+"\n"))
+	writeFile(t, filepath.Join(gitDir, "refs", "tags", "replaced"), []byte(otherCommit+"\n"))
+
+	// A loose annotated tag object pointing at the commit
+	writeFile(t, filepath.Join(gitDir, "refs", "tags", "v1.3.0"), []byte(tagObject+"\n"))
+	body := "object " + testCommit + "\ntype commit\ntag v1.3.0\n"
+	var object bytes.Buffer
+	writer := zlib.NewWriter(&object)
+	fmt.Fprintf(writer, "tag %d\x00%s", len(body), body)
+	writer.Close()
+	writeFile(t, filepath.Join(gitDir, "objects", tagObject[:2], tagObject[2:]), object.Bytes())
+
+	// A linked work tree has a .git file pointing at the git directory
+	worktree := filepath.Join(t.TempDir(), "worktree")
+	writeFile(t, filepath.Join(worktree, ".git"), []byte("gitdir: "+gitDir+"\n"))
+
+	testCases := []struct {
+		name     string
+		dir      string
+		revision string
+		expected []string
+		err      bool
+	}{
+		{"Work tree", dir, testCommit, []string{"release/v1.2.0", "v1.1.0", "v1.3.0"}, false},
+		{"Git directory", gitDir, testCommit[:7], []string{"release/v1.2.0", "v1.1.0", "v1.3.0"}, false},
+		{"Linked work tree", worktree, otherCommit, []string{"replaced", "v1.0.0"}, false},
+		{"Unknown commit", dir, "abcdef0123456", nil, false},
+		{"Not a repository", t.TempDir(), testCommit, nil, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := GitTags{Dir: tc.dir}.TagsForCommit(context.Background(), tc.revision)
+			if (err != nil) != tc.err {
+				t.Fatalf("Expected error %v, got %v", tc.err, err)
+			}
+			slices.Sort(actual)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// slowProvider blocks until its context ends.
+type slowProvider struct{}
+
+func (slowProvider) TagsForCommit(ctx context.Context, revision string) ([]string, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestCacheTags(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	provider := &fakeProvider{tags: []string{"v1.0.0"}}
+	cache := CacheTags(provider, time.Hour).(*tagCache)
+	cache.now = func() time.Time { return now }
+
+	lookup := func() {
+		if _, err := cache.TagsForCommit(context.Background(), "abc"); err != nil && provider.err == nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	lookup()
+	lookup()
+	if provider.calls != 1 {
+		t.Errorf("Expected 1 lookup within the TTL, got %d", provider.calls)
+	}
+
+	now = now.Add(2 * time.Hour)
+	provider.err = errors.New("unreachable")
+	lookup()
+	lookup()
+	if provider.calls != 2 {
+		t.Errorf("Expected a new lookup after the TTL, got %d", provider.calls)
+	}
+
+	// Failures are only remembered for a minute
+	now = now.Add(2 * time.Minute)
+	lookup()
+	if provider.calls != 3 {
+		t.Errorf("Expected a new lookup a minute after a failure, got %d", provider.calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	now = now.Add(2 * time.Minute)
+	cache.TagsForCommit(ctx, "abc")
+	lookup()
+	if provider.calls != 5 {
+		t.Errorf("Expected a cancelled lookup not to be cached, got %d lookups", provider.calls)
+	}
+}
+
+func TestLimitTags(t *testing.T) {
+	start := time.Now()
+	_, err := LimitTags(slowProvider{}, 10*time.Millisecond).TagsForCommit(context.Background(), testCommit)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the lookup to stop after the timeout, took %s", elapsed)
+	}
+}
+
+func TestNewTagProvider(t *testing.T) {
+	original := buildVersion
+	defer func() { buildVersion = original }()
+	buildVersion = func() VersionInfo { return VersionInfo{Module: "example.com/module"} }
+
+	testCases := []struct {
+		name     string
+		cfg      func(*config.Version)
+		expected []string
+		err      bool
+	}{
+		{"None", func(v *config.Version) {}, nil, false},
+		{"Static", func(v *config.Version) { v.TagProvider, v.StaticTags = config.TagProviderStatic, "v1.0.0, latest" }, []string{"latest", "v1.0.0"}, false},
+		{"GitHub repository", func(v *config.Version) { v.TagProvider, v.GitHub.Repository = config.TagProviderGitHub, "owner/repo" }, nil, false},
+		{"GitHub module off github.com", func(v *config.Version) { v.TagProvider = config.TagProviderGitHub }, nil, true},
+		{"Unknown", func(v *config.Version) { v.TagProvider = "gitlab" }, nil, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.Default().Version
+			tc.cfg(&cfg)
+			provider, err := NewTagProvider(cfg)
+			if (err != nil) != tc.err {
+				t.Fatalf("Expected error %v, got %v", tc.err, err)
+			}
+			if provider == nil || tc.expected == nil {
+				return
+			}
+			actual, err := provider.TagsForCommit(context.Background(), testCommit)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
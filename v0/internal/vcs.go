@@ -1,124 +1,144 @@
 package internal
 
 import (
+	"context"
 	"fmt"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/buildinfo"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/svggen"
 	"github.com/gin-gonic/gin"
-	"golang.org/x/net/html"
-	"io"
-	"log"
 	"log/slog"
 	"net/http"
-	"runtime/debug"
+	"slices"
 	"strings"
+	"sync"
 )
 
-func HandleVersion(c *gin.Context) {
-	info, _ := debug.ReadBuildInfo()
-	vcsRevision := getCommitHashFromSettings(info.Settings)
-	var tags []string
-	if vcsRevision != "" {
-		slog.Info(fmt.Sprintf("vcs.revision=%s", vcsRevision))
-		var err error
-		tags, err = GetTagsForCommit(info.Main.Path, vcsRevision)
-		if err != nil {
-			slog.Error("Error Retrieving Version Tags", "error", err)
-		}
-	} else {
-		slog.Error("No Commit Hash Found")
-	}
-	slog.Info(fmt.Sprintf("Tags:%+v", tags))
-	c.JSON(http.StatusOK, gin.H{"tags": tags, "revision": vcsRevision})
-}
+// Build metadata injected by the linker, for example
+//
+//	go build -ldflags "-X github.com/2ajoyce/dynamic-readme-elements/v0/internal.version=v1.4.2
+//	  -X github.com/2ajoyce/dynamic-readme-elements/v0/internal.buildTime=2024-05-01T12:00:00Z"
+//
+// Each one left empty falls back to what the Go toolchain records in the binary.
+var (
+	version   string // Release version, such as "v1.4.2"
+	revision  string // Commit the binary was built from
+	buildTime string // Time of the build, preferably RFC 3339
+	tags      string // Comma separated tags of the commit
+)
 
-// getCommitHashFromSettings takes a slice of debug.ModuleBuildInfo and iterates over it,
-// looking for a key of "vcs.revision".
-// When found, it returns the corresponding value as a string.
-// If "vcs.revision" is not found in the settings, it returns an empty string.
-func getCommitHashFromSettings(settings []debug.BuildSetting) string {
-	var commitHash string
-	for _, setting := range settings {
-		if setting.Key == "vcs.revision" {
-			commitHash = setting.Value
-			break
-		}
-	}
-	return commitHash
+// VersionInfo describes the running build.
+type VersionInfo struct {
+	Version    string   `json:"version"`              // Release version, the first tag when none was set at build time
+	Revision   string   `json:"revision"`             // Commit hash, empty when the build has no VCS information
+	Tags       []string `json:"tags"`                 // Tags of the commit
+	Dirty      bool     `json:"dirty"`                // Whether the working tree had uncommitted changes
+	BuildTime  string   `json:"buildTime,omitempty"`  // Set with -ldflags
+	CommitTime string   `json:"commitTime,omitempty"` // Time of the commit, from the VCS information
+	GoVersion  string   `json:"goVersion"`
+	Module     string   `json:"module"` // Module path of the main package
 }
 
-// getOwnerAndRepo takes a string path of format "github.com/owner/repo/version"
-// and returns the "owner" and "repo" as string values.
-// Returns an error if the path format is invalid.
-func getOwnerAndRepo(path string) (string, string, error) {
-	split := strings.Split(path, "/")
-	if len(split) < 3 {
-		return "", "", fmt.Errorf("invalid path format")
+// ShortRevision returns the first 7 characters of the revision.
+func (v VersionInfo) ShortRevision() string {
+	if len(v.Revision) > 7 {
+		return v.Revision[:7]
 	}
-	return split[1], split[2], nil
+	return v.Revision
 }
 
-// GetTagsForCommit takes a string path (format "github.com/owner/repo/version")
-// and a commit SHA string as arguments.
-// It makes a GET request to https://github.com/{owner}/{repo}/branch_commits/{commitSha},
-// parses the response body as HTML, and returns the tags associated with the commit.
-// Returns an error if the HTTP request fails, or if the HTML parsing fails.
-func GetTagsForCommit(path, commitSha string) ([]string, error) {
-	owner, repo, err := getOwnerAndRepo(path)
-	if err != nil {
-		return nil, err
-	}
+// buildVersion reads the build metadata once, see readBuildVersion.
+var buildVersion = sync.OnceValue(readBuildVersion)
 
-	resp, err := http.Get(fmt.Sprintf("https://github.com/%s/%s/branch_commits/%s", owner, repo, commitSha))
-	if err != nil {
-		return nil, err
+// readBuildVersion returns the metadata recorded by the toolchain, replaced by the
+// values given with -ldflags where those are set.
+func readBuildVersion() VersionInfo {
+	info := buildinfo.Read()
+	v := VersionInfo{
+		Version:    info.Version,
+		Revision:   info.Revision,
+		Dirty:      info.Dirty,
+		CommitTime: info.CommitTime,
+		GoVersion:  info.GoVersion,
+		Module:     info.Module,
 	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
+	if version != "" {
+		v.Version = version
+	}
+	if revision != "" {
+		v.Revision = revision
+	}
+	v.BuildTime = buildTime
+	v.Tags = mergeTags(nil, strings.Split(tags, ","))
+	return v
+}
+
+// CurrentVersion returns the metadata of the running build, with the tags of its
+// revision found by provider added to those set at build time. provider may be nil.
+// A failed lookup is logged and leaves the build time tags.
+func CurrentVersion(ctx context.Context, provider TagProvider) VersionInfo {
+	v := buildVersion()
+	v.Tags = slices.Clone(v.Tags)
+	if provider != nil && v.Revision != "" {
+		found, err := provider.TagsForCommit(ctx, v.Revision)
 		if err != nil {
-			log.Printf("Failed to close the response body: %v", err)
+			slog.Error("Error retrieving version tags", "revision", v.Revision, "error", err)
 		}
-	}(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP request failed with status code: %d", resp.StatusCode)
+		v.Tags = mergeTags(v.Tags, found)
+	}
+	if v.Version == "" && len(v.Tags) > 0 {
+		v.Version = v.Tags[0]
 	}
+	return v
+}
 
-	doc, err := html.Parse(resp.Body)
-	if err != nil {
-		return nil, err
+// HandleVersion returns a handler responding with the CurrentVersion as JSON.
+func HandleVersion(provider TagProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, CurrentVersion(c.Request.Context(), provider))
 	}
+}
 
-	tags := extractTags(doc)
-	return tags, nil
+// HandleVersionBadge returns a handler drawing the CurrentVersion as a badge, such as
+// "version | v1.4.2". Builds without a version show their short revision instead.
+func HandleVersionBadge(provider TagProvider) gin.HandlerFunc {
+	return svggen.BadgeHandler(func(c *gin.Context) (label, message string) {
+		return "version", versionMessage(CurrentVersion(c.Request.Context(), provider))
+	})
 }
 
-// extractTags takes an *html.Node and traverses the node tree,
-// extracting the text content of <a> elements within the second <ul> element it encounters.
-// Returns a slice of strings containing the extracted tags.
-func extractTags(n *html.Node) []string {
-	var ulCounter = 0
-	var result []string
-	var f func(*html.Node)
+// versionMessage returns the version, the short revision or "unknown", whichever is known first.
+func versionMessage(v VersionInfo) string {
+	switch {
+	case v.Version != "":
+		return v.Version
+	case v.Revision != "":
+		return v.ShortRevision()
+	}
+	return "unknown"
+}
 
-	f = func(n *html.Node) {
-		if n.Type == html.ElementNode && n.Data == "ul" {
-			ulCounter++
-			if ulCounter == 2 {
-				for c := n.FirstChild; c != nil; c = c.NextSibling {
-					if c.Type == html.ElementNode && c.Data == "li" {
-						for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
-							if cc.Type == html.ElementNode && cc.Data == "a" {
-								result = append(result, cc.FirstChild.Data)
-							}
-						}
-					}
-				}
-			}
-		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			f(c)
+// mergeTags adds the non-empty tags of extra to tags, sorted and without duplicates.
+// The result is never nil, so it encodes as an empty JSON list.
+func mergeTags(tags, extra []string) []string {
+	merged := append([]string{}, tags...)
+	for _, tag := range extra {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			merged = append(merged, tag)
 		}
 	}
+	slices.Sort(merged)
+	return slices.Compact(merged)
+}
 
-	f(n)
-	return result
+// getOwnerAndRepo takes a module path such as "github.com/owner/repo/v0" and returns the
+// host, owner and repository it is hosted at. Paths on other hosts, such as a GitHub
+// Enterprise server at "git.example.com/owner/repo", are split the same way, and a ".git"
+// suffix on the repository is dropped.
+// Returns an error if the path has no owner and repository after the host.
+func getOwnerAndRepo(path string) (host, owner, repo string, err error) {
+	split := strings.Split(path, "/")
+	if len(split) < 3 || split[0] == "" || split[1] == "" || strings.TrimSuffix(split[2], ".git") == "" {
+		return "", "", "", fmt.Errorf("invalid path format: %q is not host/owner/repo", path)
+	}
+	return split[0], split[1], strings.TrimSuffix(split[2], ".git"), nil
 }
@@ -0,0 +1,197 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeProvider returns fixed tags, counting how often it is asked.
+type fakeProvider struct {
+	tags  []string
+	err   error
+	calls int
+}
+
+func (p *fakeProvider) TagsForCommit(ctx context.Context, revision string) ([]string, error) {
+	p.calls++
+	return p.tags, p.err
+}
+
+func TestHandleVersion(t *testing.T) {
+	router := gin.Default()
+	router.GET("/version", HandleVersion(nil))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/version", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, key := range []string{"version", "revision", "tags", "dirty", "goVersion", "module"} {
+		if _, ok := body[key]; !ok {
+			t.Errorf("Expected key %q in %s", key, w.Body.String())
+		}
+	}
+	if tags, ok := body["tags"].([]any); !ok || tags == nil {
+		t.Errorf("Expected tags to be a list, got %v", body["tags"])
+	}
+}
+
+func TestCurrentVersionTags(t *testing.T) {
+	// Test binaries carry no VCS information
+	original := buildVersion
+	defer func() { buildVersion = original }()
+	buildVersion = func() VersionInfo { return VersionInfo{Revision: "0123456789abcdef", Tags: []string{}} }
+
+	testCases := []struct {
+		name     string
+		provider *fakeProvider
+		expected []string
+	}{
+		{"Provider tags", &fakeProvider{tags: []string{"v1.1.0", "v1.0.0", "v1.1.0"}}, []string{"v1.0.0", "v1.1.0"}},
+		{"Provider error", &fakeProvider{err: errors.New("unreachable")}, []string{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := CurrentVersion(context.Background(), tc.provider)
+			if !reflect.DeepEqual(v.Tags, tc.expected) {
+				t.Errorf("Expected tags %v, got %v", tc.expected, v.Tags)
+			}
+			if len(tc.expected) > 0 && v.Version != tc.expected[0] {
+				t.Errorf("Expected version %s, got %s", tc.expected[0], v.Version)
+			}
+		})
+	}
+}
+
+func TestBuildVersionLdflags(t *testing.T) {
+	defer func(v, r, b, tg string) { version, revision, buildTime, tags = v, r, b, tg }(version, revision, buildTime, tags)
+	version, revision, buildTime, tags = "v1.4.2", "0123456789abcdef", "2024-05-01T12:00:00Z", "latest,v1.4.2"
+
+	v := readBuildVersion()
+	if v.Version != "v1.4.2" || v.Revision != "0123456789abcdef" || v.BuildTime != "2024-05-01T12:00:00Z" {
+		t.Errorf("Expected the -ldflags values, got %+v", v)
+	}
+	if !reflect.DeepEqual(v.Tags, []string{"latest", "v1.4.2"}) {
+		t.Errorf("Expected the -ldflags tags, got %v", v.Tags)
+	}
+
+	// The metrics are labeled from the same metadata, as main wires them
+	var buf bytes.Buffer
+	if _, err := metrics.New(v.Version, v.Revision).WriteTo(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := `dre_build_info{version="v1.4.2",revision="0123456789abcdef",`; !strings.Contains(buf.String(), expected) {
+		t.Errorf("Expected %s in the metrics, got:\n%s", expected, buf.String())
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	testCases := []struct {
+		name     string
+		tags     []string
+		extra    []string
+		expected []string
+	}{
+		{"Empty", nil, []string{""}, []string{}},
+		{"Sorted without duplicates", []string{"v2"}, []string{" v1", "v2", ""}, []string{"v1", "v2"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := mergeTags(tc.tags, tc.extra); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestShortRevision(t *testing.T) {
+	testCases := []struct {
+		revision string
+		expected string
+	}{
+		{"0123456789abcdef", "0123456"},
+		{"abc", "abc"},
+		{"", ""},
+	}
+	for _, tc := range testCases {
+		if actual := (VersionInfo{Revision: tc.revision}).ShortRevision(); actual != tc.expected {
+			t.Errorf("Expected %q, got %q", tc.expected, actual)
+		}
+	}
+}
+
+func TestGetOwnerAndRepo(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected [3]string
+		err      bool
+	}{
+		{"github.com/owner/repo/v0", [3]string{"github.com", "owner", "repo"}, false},
+		{"git.example.com/owner/repo.git", [3]string{"git.example.com", "owner", "repo"}, false},
+		{"github.com/owner", [3]string{}, true},
+		{"github.com//repo", [3]string{}, true},
+		{"", [3]string{}, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			host, owner, repo, err := getOwnerAndRepo(tc.path)
+			if (err != nil) != tc.err {
+				t.Fatalf("Expected error %v, got %v", tc.err, err)
+			}
+			if actual := [3]string{host, owner, repo}; actual != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHandleVersionBadge(t *testing.T) {
+	original := buildVersion
+	defer func() { buildVersion = original }()
+
+	testCases := []struct {
+		name     string
+		build    VersionInfo
+		provider TagProvider
+		expected string
+	}{
+		{"Version", VersionInfo{Version: "v1.4.2", Revision: "0123456789abcdef"}, nil, ">v1.4.2</text>"},
+		{"Tag from provider", VersionInfo{Revision: "0123456789abcdef"}, StaticTags{"v1.5.0"}, ">v1.5.0</text>"},
+		{"Short revision", VersionInfo{Revision: "0123456789abcdef"}, nil, ">0123456</text>"},
+		{"Unknown", VersionInfo{}, nil, ">unknown</text>"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buildVersion = func() VersionInfo { return tc.build }
+			router := gin.Default()
+			router.GET("/badge/version", HandleVersionBadge(tc.provider))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/badge/version", nil)
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			if body := w.Body.String(); !strings.Contains(body, tc.expected) || !strings.Contains(body, ">version</text>") {
+				t.Errorf("Expected to find %s in response body %s", tc.expected, body)
+			}
+		})
+	}
+}
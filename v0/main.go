@@ -1,18 +1,66 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"github.com/2ajoyce/dynamic-readme-elements/v0/internal"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/cli"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/config"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/metrics"
+	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/server"
 	"github.com/2ajoyce/dynamic-readme-elements/v0/internal/svggen"
 	"github.com/gin-gonic/gin"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
+	// Subcommands render charts locally instead of starting the server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "render":
+			os.Exit(cli.Render(os.Args[2:], os.Stdout, os.Stderr))
+		case "update":
+			os.Exit(cli.Update(os.Args[2:], os.Stdout, os.Stderr))
+		}
+	}
+
+	// Settings come from an optional config file and DRE_* environment variables
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	configPath := flags.String("config", os.Getenv("DRE_CONFIG"), "YAML or TOML file with the server settings")
+	_ = flags.Parse(os.Args[1:])
+	cfg, err := config.Load(*configPath, os.LookupEnv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+	svggen.SetSettings(cfg.ChartSettings())
+	svggen.SetCachePolicy(cfg.CachePolicy())
+	svggen.SetRenderCacheSize(cfg.Cache.RenderCacheSize)
+
+	// The ETags, /version and /metrics all describe the build with the same metadata
+	build := internal.CurrentVersion(context.Background(), nil)
+	svggen.SetBuildID(build.Version + " " + build.Revision)
+
 	router := gin.Default()
 
+	// Every request is counted and timed for the /metrics endpoint
+	stats := metrics.New(build.Version, build.Revision)
+	if cfg.Features.Metrics {
+		router.Use(stats.Middleware())
+	}
+
 	// Route for a calendar
 	router.GET("/calendar", svggen.HandleCalendar)
 
+	// Route for a year long contribution heatmap
+	router.GET("/calendar/year", svggen.HandleCalendarYear)
+
 	// Route for a rectangular loading bar
 	router.GET("/progress/bar", svggen.HandleProgressBar)
 
@@ -25,16 +73,37 @@ func main() {
 	// Route for a waffle progress chart
 	router.GET("/progress/waffle", svggen.HandleProgressWaffle)
 
+	// Route for a label and message badge
+	router.GET("/badge", svggen.HandleBadge)
+
 	// Route for health check
 	router.GET("/health", func(c *gin.Context) {
 		c.Status(http.StatusOK)
 	})
 
-	// Route for version endpoint
-	router.GET("/version", internal.HandleVersion)
+	// Routes for the version of the running build
+	if cfg.Features.Version {
+		tagProvider, err := internal.NewTagProvider(cfg.Version)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+			os.Exit(1)
+		}
+		router.GET("/version", internal.HandleVersion(tagProvider))
+		router.GET("/badge/version", internal.HandleVersionBadge(tagProvider))
+	}
 
-	err := router.Run(":8080")
-	if err != nil {
-		return
+	// Route for Prometheus metrics
+	if cfg.Features.Metrics {
+		router.GET("/metrics", stats.Handle)
+	}
+
+	// Serve until SIGINT or SIGTERM, then let the requests in flight finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	srv := server.New(cfg.Addr, router, cfg.Server)
+	if err := server.Run(ctx, srv, time.Duration(cfg.Server.ShutdownTimeout)); err != nil {
+		slog.Error("Server stopped", "error", err)
+		stop()
+		os.Exit(1)
 	}
 }